@@ -13,6 +13,6 @@ require (
 	github.com/nats-io/jwt v1.2.2 // indirect
 	github.com/nats-io/nats.go v1.10.0
 	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad // indirect
-	golang.org/x/image v0.0.0-20201208152932-35266b937fa6 // indirect
+	golang.org/x/image v0.0.0-20201208152932-35266b937fa6
 	golang.org/x/sys v0.0.0-20210124154548-22da62e12c0c // indirect
 )
@@ -0,0 +1,76 @@
+package handler
+
+import (
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/nicolasparada/nakama/internal/service"
+)
+
+// 导出当前用户的所有数据，每一行是一个 JSON 对象
+func (h *handler) export(w http.ResponseWriter, r *http.Request) {
+	rc, err := h.Export(r.Context())
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrUserNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	defer rc.Close()
+
+	header := w.Header()
+	header.Set("Content-Type", "application/x-ndjson; charset=utf-8")
+	header.Set("Content-Disposition", `attachment; filename="nakama-export.jsonl"`)
+	header.Set("Cache-Control", "no-store")
+	if _, err = io.Copy(w, rc); err != nil {
+		// Headers already sent; the client gets a truncated file.
+		log.Printf("could not write export: %v\n", err)
+	}
+}
+
+// 删除当前用户的账号，在后台执行
+func (h *handler) deleteAccount(w http.ResponseWriter, r *http.Request) {
+	d, err := h.DeleteAccount(r.Context())
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, d, http.StatusAccepted)
+}
+
+// 查询账号删除的进度
+func (h *handler) accountDeletion(w http.ResponseWriter, r *http.Request) {
+	d, err := h.AccountDeletion(r.Context())
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrAccountDeletionNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, d, http.StatusOK)
+}
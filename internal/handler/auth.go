@@ -71,6 +71,74 @@ func (h *handler) authRedirect(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, uri, http.StatusFound)
 }
 
+type updateEmailInput struct {
+	Email       string
+	RedirectURI string
+}
+
+func (h *handler) updateEmail(w http.ResponseWriter, r *http.Request) {
+	var in updateEmailInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.UpdateEmail(r.Context(), in.Email, in.RedirectURI)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidEmail || err == service.ErrInvalidRedirectURI {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrUserNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrEmailTaken {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) verifyEmail(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	uri, err := h.VerifyEmail(r.Context(), q.Get("verification_code"), q.Get("redirect_uri"))
+	if err == service.ErrInvalidVerificationCode || err == service.ErrInvalidRedirectURI {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrVerificationCodeNotFound || err == service.ErrExpiredToken {
+		http.Error(w, err.Error(), http.StatusGone)
+		return
+	}
+
+	if err == service.ErrEmailTaken {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	http.Redirect(w, r, uri, http.StatusFound)
+}
+
 func (h *handler) devLogin(w http.ResponseWriter, r *http.Request) {
 	var in loginInput
 	defer r.Body.Close()
@@ -161,6 +229,21 @@ func (h *handler) withAuth(next http.Handler) http.Handler {
 
 		ctx := r.Context()
 		ctx = context.WithValue(ctx, service.KeyAuthUserID, uid)
+
+		// 正在删除的账号只能读取，比如查看删除进度
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			d, err := h.AccountDeletion(ctx)
+			if err != nil && err != service.ErrAccountDeletionNotFound {
+				respondErr(w, err)
+				return
+			}
+
+			if err == nil && d.Status == "running" {
+				http.Error(w, service.ErrAccountBeingDeleted.Error(), http.StatusForbidden)
+				return
+			}
+		}
+
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
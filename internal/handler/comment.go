@@ -11,7 +11,8 @@ import (
 )
 
 type createCommentInput struct {
-	Content string
+	Content  string
+	ParentID *string
 }
 
 func (h *handler) createComment(w http.ResponseWriter, r *http.Request) {
@@ -24,22 +25,29 @@ func (h *handler) createComment(w http.ResponseWriter, r *http.Request) {
 
 	ctx := r.Context()
 	postID := way.Param(ctx, "post_id")
-	c, err := h.CreateComment(ctx, postID, in.Content)
+	c, err := h.CreateComment(ctx, postID, in.Content, in.ParentID)
 	if err == service.ErrUnauthenticated {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	if err == service.ErrInvalidPostID || err == service.ErrInvalidContent {
+	if err == service.ErrInvalidPostID ||
+		err == service.ErrInvalidCommentID ||
+		err == service.ErrInvalidContent {
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
-	if err == service.ErrPostNotFound {
+	if err == service.ErrPostNotFound || err == service.ErrCommentNotFound {
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
 
+	if err == service.ErrUserBlocked || err == service.ErrPrivateAccount {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -60,11 +68,51 @@ func (h *handler) comments(w http.ResponseWriter, r *http.Request) {
 	last, _ := strconv.Atoi(q.Get("last"))
 	before := q.Get("before")
 	cc, err := h.Comments(ctx, postID, last, before)
-	if err == service.ErrInvalidPostID || err == service.ErrInvalidCommentID {
+	if err == service.ErrInvalidPostID || err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrPrivateAccount {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, cc, http.StatusOK)
+}
+
+func (h *handler) commentReplies(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	commentID := way.Param(ctx, "comment_id")
+	last, _ := strconv.Atoi(q.Get("last"))
+	before := q.Get("before")
+	cc, err := h.CommentReplies(ctx, commentID, last, before)
+	if err == service.ErrInvalidCommentID || err == service.ErrInvalidCursor {
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrPrivateAccount {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -88,6 +136,16 @@ func (h *handler) commentStream(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrPrivateAccount {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -104,6 +162,81 @@ func (h *handler) commentStream(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+type updateCommentInput struct {
+	Content string
+}
+
+func (h *handler) updateComment(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var in updateCommentInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	commentID := way.Param(ctx, "comment_id")
+	c, err := h.UpdateComment(ctx, commentID, in.Content)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidCommentID || err == service.ErrInvalidContent {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrCommentNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrPermissionDenied {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, c, http.StatusOK)
+}
+
+func (h *handler) deleteComment(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	commentID := way.Param(ctx, "comment_id")
+	err := h.DeleteComment(ctx, commentID)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidCommentID {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrCommentNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrPermissionDenied {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *handler) toggleCommentLike(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	commentID := way.Param(ctx, "comment_id")
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/matryer/way"
+	"github.com/nicolasparada/nakama/internal/service"
+)
+
+type createConversationInput struct {
+	Usernames []string
+}
+
+// 和一个或多个用户开始私信对话，一对一的对话已存在时直接返回
+func (h *handler) createConversation(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var in createConversationInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.CreateConversation(r.Context(), in.Usernames)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidUsername || err == service.ErrInvalidConversationMembers {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrUserNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrUserBlocked {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, c, http.StatusCreated)
+}
+
+// 获取当前登录用户的对话，Accept 为 text/event-stream 时改为实时接收私信
+func (h *handler) conversations(w http.ResponseWriter, r *http.Request) {
+	if a, _, err := mime.ParseMediaType(r.Header.Get("Accept")); err == nil && a == "text/event-stream" {
+		h.messageStream(w, r)
+		return
+	}
+
+	q := r.URL.Query()
+	last, _ := strconv.Atoi(q.Get("last"))
+	before := q.Get("before")
+	cc, err := h.Conversations(r.Context(), last, before)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, cc, http.StatusOK)
+}
+
+func (h *handler) messageStream(w http.ResponseWriter, r *http.Request) {
+	f, ok := w.(http.Flusher)
+	if !ok {
+		respondErr(w, errStreamingUnsupported)
+		return
+	}
+
+	mm, err := h.MessageStream(r.Context())
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("Content-Type", "text/event-stream; charset=utf-8")
+
+	for m := range mm {
+		writeSSE(w, m)
+		f.Flush()
+	}
+}
+
+func (h *handler) conversation(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	conversationID := way.Param(ctx, "conversation_id")
+	c, err := h.Conversation(ctx, conversationID)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidConversationID {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrConversationNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, c, http.StatusOK)
+}
+
+func (h *handler) messages(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	conversationID := way.Param(ctx, "conversation_id")
+	last, _ := strconv.Atoi(q.Get("last"))
+	before := q.Get("before")
+	mm, err := h.Messages(ctx, conversationID, last, before)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidConversationID || err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrConversationNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, mm, http.StatusOK)
+}
+
+type sendMessageInput struct {
+	Content string
+}
+
+func (h *handler) sendMessage(w http.ResponseWriter, r *http.Request) {
+	defer r.Body.Close()
+	var in sendMessageInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	conversationID := way.Param(ctx, "conversation_id")
+	m, err := h.SendMessage(ctx, conversationID, in.Content)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidConversationID || err == service.ErrInvalidContent {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrConversationNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrUserBlocked {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, m, http.StatusCreated)
+}
+
+func (h *handler) hasUnreadMessages(w http.ResponseWriter, r *http.Request) {
+	unread, err := h.HasUnreadMessages(r.Context())
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, unread, http.StatusOK)
+}
+
+func (h *handler) markConversationAsRead(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	conversationID := way.Param(ctx, "conversation_id")
+	err := h.MarkConversationAsRead(ctx, conversationID)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidConversationID {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -6,6 +6,7 @@ import (
 	"context"
 	"io"
 	"net/http"
+	"time"
 
 	"github.com/matryer/way"
 	"github.com/nicolasparada/nakama/internal/service"
@@ -23,36 +24,78 @@ type Service interface {
 	AuthUserIDFromToken(token string) (string, error)
 	AuthUser(ctx context.Context) (service.User, error)
 	Token(ctx context.Context) (service.TokenOutput, error)
+	UpdateEmail(ctx context.Context, email, redirectURI string) error
+	VerifyEmail(ctx context.Context, verificationCode, redirectURI string) (string, error)
+	Export(ctx context.Context) (io.ReadCloser, error)
+	DeleteAccount(ctx context.Context) (service.AccountDeletion, error)
+	AccountDeletion(ctx context.Context) (service.AccountDeletion, error)
 
-	CreateComment(ctx context.Context, postID string, content string) (service.Comment, error)
-	Comments(ctx context.Context, postID string, last int, before string) ([]service.Comment, error)
+	CreateComment(ctx context.Context, postID string, content string, parentID *string) (service.Comment, error)
+	Comments(ctx context.Context, postID string, last int, before string) (service.CommentsOutput, error)
+	CommentReplies(ctx context.Context, commentID string, last int, before string) (service.CommentsOutput, error)
 	CommentStream(ctx context.Context, postID string) (<-chan service.Comment, error)
+	UpdateComment(ctx context.Context, commentID string, content string) (service.Comment, error)
+	DeleteComment(ctx context.Context, commentID string) error
 	ToggleCommentLike(ctx context.Context, commentID string) (service.ToggleLikeOutput, error)
 
-	Notifications(ctx context.Context, last int, before string) ([]service.Notification, error)
+	Notifications(ctx context.Context, last int, before string) (service.NotificationsOutput, error)
 	NotificationStream(ctx context.Context) (<-chan service.Notification, error)
 	HasUnreadNotifications(ctx context.Context) (bool, error)
 	MarkNotificationAsRead(ctx context.Context, notificationID string) error
 	MarkNotificationsAsRead(ctx context.Context) error
 
-	CreatePost(ctx context.Context, content string, spoilerOf *string, nsfw bool) (service.TimelineItem, error)
-	Posts(ctx context.Context, username string, last int, before string) ([]service.Post, error)
+	CreateConversation(ctx context.Context, usernames []string) (service.Conversation, error)
+	Conversations(ctx context.Context, last int, before string) (service.ConversationsOutput, error)
+	Conversation(ctx context.Context, conversationID string) (service.Conversation, error)
+	Messages(ctx context.Context, conversationID string, last int, before string) (service.MessagesOutput, error)
+	SendMessage(ctx context.Context, conversationID, content string) (service.Message, error)
+	MessageStream(ctx context.Context) (<-chan service.Message, error)
+	HasUnreadMessages(ctx context.Context) (bool, error)
+	MarkConversationAsRead(ctx context.Context, conversationID string) error
+
+	CreatePost(ctx context.Context, content string, spoilerOf *string, nsfw bool, visibility string, quotedPostID *string, media []io.Reader) (service.TimelineItem, error)
+	Posts(ctx context.Context, username string, last int, before string) (service.PostsOutput, error)
 	Post(ctx context.Context, postID string) (service.Post, error)
+	UpdatePost(ctx context.Context, postID string, content, spoilerOf *string, nsfw *bool) (service.Post, error)
+	PostRevisions(ctx context.Context, postID string) ([]service.PostRevision, error)
+	DeletePost(ctx context.Context, postID string) error
 	TogglePostLike(ctx context.Context, postID string) (service.ToggleLikeOutput, error)
 	TogglePostSubscription(ctx context.Context, postID string) (service.ToggleSubscriptionOutput, error)
+	Repost(ctx context.Context, postID string) (service.RepostOutput, error)
+	Unrepost(ctx context.Context, postID string) (service.RepostOutput, error)
+
+	SearchPosts(ctx context.Context, q string, first int, after string) (service.SearchPostsOutput, error)
+	TagPosts(ctx context.Context, tag string, last int, before string) (service.PostsOutput, error)
+	TrendingTags(ctx context.Context, window time.Duration, first int) ([]service.TrendingTag, error)
 
-	Timeline(ctx context.Context, last int, before string) ([]service.TimelineItem, error)
+	Timeline(ctx context.Context, last int, before string) (service.TimelineOutput, error)
+	RankedTimeline(ctx context.Context, first int, after string) (service.RankedTimelineOutput, error)
 	TimelineItemStream(ctx context.Context) (<-chan service.TimelineItem, error)
 	DeleteTimelineItem(ctx context.Context, timelineItemID string) error
 
 	CreateUser(ctx context.Context, email, username string) error
-	Users(ctx context.Context, search string, first int, after string) ([]service.UserProfile, error)
-	Usernames(ctx context.Context, startingWith string, first int, after string) ([]string, error)
+	Users(ctx context.Context, search string, first int, after string) (service.UsersOutput, error)
+	Usernames(ctx context.Context, startingWith string, first int, after string) (service.UsernamesOutput, error)
 	User(ctx context.Context, username string) (service.UserProfile, error)
+	UpdateUser(ctx context.Context, displayName, bio, location, website *string, private *bool) (service.UserProfile, error)
+	ChangeUsername(ctx context.Context, username string) error
+	RenamedUsername(ctx context.Context, oldUsername string) (string, error)
 	UpdateAvatar(ctx context.Context, r io.Reader) (string, error)
+	DeleteAvatar(ctx context.Context) error
+	Identicon(username string, size int) ([]byte, error)
 	ToggleFollow(ctx context.Context, username string) (service.ToggleFollowOutput, error)
 	Followers(ctx context.Context, username string, first int, after string) ([]service.UserProfile, error)
 	Followees(ctx context.Context, username string, first int, after string) ([]service.UserProfile, error)
+	ToggleBlock(ctx context.Context, username string) (service.ToggleBlockOutput, error)
+	Blocks(ctx context.Context, first int, after string) ([]service.UserProfile, error)
+	MuteUser(ctx context.Context, username string, expiresAt *time.Time) (service.Mute, error)
+	MuteWord(ctx context.Context, word string, expiresAt *time.Time) (service.Mute, error)
+	Mutes(ctx context.Context) ([]service.Mute, error)
+	UpdateMute(ctx context.Context, muteID string, expiresAt *time.Time) (service.Mute, error)
+	DeleteMute(ctx context.Context, muteID string) error
+	FollowRequests(ctx context.Context, first int, after string) ([]service.UserProfile, error)
+	AcceptFollowRequest(ctx context.Context, username string) error
+	RejectFollowRequest(ctx context.Context, username string) error
 }
 
 // New makes use of the service to provide an http.Handler with predefined routing.
@@ -64,29 +107,65 @@ func New(s Service, dev bool) http.Handler {
 	api.HandleFunc("GET", "/auth_redirect", h.authRedirect)
 	api.HandleFunc("POST", "/dev_login", h.devLogin)
 	api.HandleFunc("GET", "/auth_user", h.authUser)
+	api.HandleFunc("PATCH", "/auth_user", h.updateUser)
+	api.HandleFunc("DELETE", "/auth_user", h.deleteAccount)
+	api.HandleFunc("GET", "/auth_user/deletion", h.accountDeletion)
+	api.HandleFunc("GET", "/auth_user/export", h.export)
+	api.HandleFunc("GET", "/auth_user/blocks", h.blocks)
+	api.HandleFunc("GET", "/auth_user/follow_requests", h.followRequests)
+	api.HandleFunc("POST", "/auth_user/follow_requests/:username/accept", h.acceptFollowRequest)
+	api.HandleFunc("POST", "/auth_user/follow_requests/:username/reject", h.rejectFollowRequest)
+	api.HandleFunc("GET", "/auth_user/mutes", h.mutes)
+	api.HandleFunc("POST", "/auth_user/mutes", h.createMute)
+	api.HandleFunc("PATCH", "/auth_user/mutes/:mute_id", h.updateMute)
+	api.HandleFunc("DELETE", "/auth_user/mutes/:mute_id", h.deleteMute)
 	api.HandleFunc("GET", "/token", h.token)
+	api.HandleFunc("PUT", "/auth_user/email", h.updateEmail)
+	api.HandleFunc("GET", "/verify_email", h.verifyEmail)
 	api.HandleFunc("POST", "/users", h.createUser)
 	api.HandleFunc("GET", "/users", h.users)
 	api.HandleFunc("GET", "/usernames", h.usernames)
 	api.HandleFunc("GET", "/users/:username", h.user)
+	api.HandleFunc("PUT", "/auth_user/username", h.changeUsername)
 	api.HandleFunc("PUT", "/auth_user/avatar", h.updateAvatar)
+	api.HandleFunc("DELETE", "/auth_user/avatar", h.deleteAvatar)
+	api.HandleFunc("GET", "/identicons/:username", h.identicon)
 	api.HandleFunc("POST", "/users/:username/toggle_follow", h.toggleFollow)
 	api.HandleFunc("GET", "/users/:username/followers", h.followers)
 	api.HandleFunc("GET", "/users/:username/followees", h.followees)
+	api.HandleFunc("POST", "/users/:username/toggle_block", h.toggleBlock)
 	api.HandleFunc("POST", "/posts", h.createPost)
 	api.HandleFunc("GET", "/users/:username/posts", h.posts)
 	api.HandleFunc("GET", "/posts/:post_id", h.post)
+	api.HandleFunc("PATCH", "/posts/:post_id", h.updatePost)
+	api.HandleFunc("DELETE", "/posts/:post_id", h.deletePost)
+	api.HandleFunc("GET", "/posts/:post_id/revisions", h.postRevisions)
 	api.HandleFunc("POST", "/posts/:post_id/toggle_like", h.togglePostLike)
 	api.HandleFunc("POST", "/posts/:post_id/toggle_subscription", h.togglePostSubscription)
+	api.HandleFunc("POST", "/posts/:post_id/repost", h.repost)
+	api.HandleFunc("DELETE", "/posts/:post_id/repost", h.unrepost)
+	api.HandleFunc("GET", "/search/posts", h.searchPosts)
+	api.HandleFunc("GET", "/tags/trending", h.trendingTags)
+	api.HandleFunc("GET", "/tags/:tag/posts", h.tagPosts)
 	api.HandleFunc("GET", "/timeline", h.timeline)
 	api.HandleFunc("DELETE", "/timeline/:timeline_item_id", h.deleteTimelineItem)
 	api.HandleFunc("POST", "/posts/:post_id/comments", h.createComment)
 	api.HandleFunc("GET", "/posts/:post_id/comments", h.comments)
+	api.HandleFunc("PATCH", "/comments/:comment_id", h.updateComment)
+	api.HandleFunc("GET", "/comments/:comment_id/replies", h.commentReplies)
+	api.HandleFunc("DELETE", "/comments/:comment_id", h.deleteComment)
 	api.HandleFunc("POST", "/comments/:comment_id/toggle_like", h.toggleCommentLike)
 	api.HandleFunc("GET", "/notifications", h.notifications)
 	api.HandleFunc("GET", "/has_unread_notifications", h.hasUnreadNotifications)
 	api.HandleFunc("POST", "/notifications/:notification_id/mark_as_read", h.markNotificationAsRead)
 	api.HandleFunc("POST", "/mark_notifications_as_read", h.markNotificationsAsRead)
+	api.HandleFunc("POST", "/conversations", h.createConversation)
+	api.HandleFunc("GET", "/conversations", h.conversations)
+	api.HandleFunc("GET", "/conversations/:conversation_id", h.conversation)
+	api.HandleFunc("GET", "/conversations/:conversation_id/messages", h.messages)
+	api.HandleFunc("POST", "/conversations/:conversation_id/messages", h.sendMessage)
+	api.HandleFunc("POST", "/conversations/:conversation_id/mark_as_read", h.markConversationAsRead)
+	api.HandleFunc("GET", "/has_unread_messages", h.hasUnreadMessages)
 
 	fs := http.FileServer(&spaFileSystem{http.Dir("web/static")})
 	if dev {
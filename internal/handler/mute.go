@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/matryer/way"
+	"github.com/nicolasparada/nakama/internal/service"
+)
+
+type createMuteInput struct {
+	Username  string
+	Word      string
+	ExpiresAt *time.Time
+}
+
+// 静音一个用户或者一个关键词，username 和 word 二选一
+func (h *handler) createMute(w http.ResponseWriter, r *http.Request) {
+	var in createMuteInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	var m service.Mute
+	var err error
+	if in.Username != "" {
+		m, err = h.MuteUser(ctx, in.Username, in.ExpiresAt)
+	} else {
+		m, err = h.MuteWord(ctx, in.Word, in.ExpiresAt)
+	}
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidUsername ||
+		err == service.ErrInvalidMutedWord ||
+		err == service.ErrInvalidMuteExpiry {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrUserNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrForbiddenMute {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, m, http.StatusCreated)
+}
+
+// 获取当前登录用户还没过期的静音
+func (h *handler) mutes(w http.ResponseWriter, r *http.Request) {
+	mm, err := h.Mutes(r.Context())
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, mm, http.StatusOK)
+}
+
+type updateMuteInput struct {
+	ExpiresAt *time.Time
+}
+
+// 修改静音的过期时间，expiresAt 为 null 表示永久静音
+func (h *handler) updateMute(w http.ResponseWriter, r *http.Request) {
+	var in updateMuteInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	muteID := way.Param(ctx, "mute_id")
+	m, err := h.UpdateMute(ctx, muteID, in.ExpiresAt)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidMuteID || err == service.ErrInvalidMuteExpiry {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrMuteNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, m, http.StatusOK)
+}
+
+// 取消静音
+func (h *handler) deleteMute(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	muteID := way.Param(ctx, "mute_id")
+	err := h.DeleteMute(ctx, muteID)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidMuteID {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
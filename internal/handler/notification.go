@@ -24,7 +24,7 @@ func (h *handler) notifications(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err == service.ErrInvalidNotificationID {
+	if err == service.ErrInvalidCursor {
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
@@ -2,38 +2,86 @@ package handler
 
 import (
 	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/matryer/way"
 	"github.com/nicolasparada/nakama/internal/service"
 )
 
+const maxPostFieldsBytes = 1 << 20 // 1MB
+
 type createPostInput struct {
-	Content   string
-	SpoilerOf *string
-	NSFW      bool
+	Content      string
+	SpoilerOf    *string
+	NSFW         bool
+	Visibility   string
+	QuotedPostID *string
 }
 
 func (h *handler) createPost(w http.ResponseWriter, r *http.Request) {
 	var in createPostInput
+	var media []io.Reader
 	defer r.Body.Close()
-	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct == "multipart/form-data" {
+		r.Body = http.MaxBytesReader(w, r.Body, service.MaxMediaPerPost*service.MaxMediaBytes+maxPostFieldsBytes)
+		if err := r.ParseMultipartForm(maxPostFieldsBytes); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		defer r.MultipartForm.RemoveAll()
+
+		in = createPostInputFromForm(r.MultipartForm.Value)
+		for _, fh := range r.MultipartForm.File["media"] {
+			f, err := fh.Open()
+			if err != nil {
+				respondErr(w, fmt.Errorf("could not open media file: %w", err))
+				return
+			}
+
+			defer f.Close()
+			media = append(media, f)
+		}
+	} else if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	ti, err := h.CreatePost(r.Context(), in.Content, in.SpoilerOf, in.NSFW)
+	ti, err := h.CreatePost(r.Context(), in.Content, in.SpoilerOf, in.NSFW, in.Visibility, in.QuotedPostID, media)
 	if err == service.ErrUnauthenticated {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
 
-	if err == service.ErrInvalidContent || err == service.ErrInvalidSpoiler {
+	if err == service.ErrInvalidContent ||
+		err == service.ErrInvalidSpoiler ||
+		err == service.ErrInvalidVisibility ||
+		err == service.ErrInvalidPostID ||
+		err == service.ErrTooManyMedia {
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
+	if err == service.ErrUnsupportedMediaFormat {
+		http.Error(w, err.Error(), http.StatusUnsupportedMediaType)
+		return
+	}
+
+	if err == service.ErrMediaTooLarge {
+		http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -42,17 +90,39 @@ func (h *handler) createPost(w http.ResponseWriter, r *http.Request) {
 	respond(w, ti, http.StatusCreated)
 }
 
+// createPostInputFromForm reads the post fields sent along with media in a multipart form.
+func createPostInputFromForm(form url.Values) createPostInput {
+	var in createPostInput
+	in.Content = form.Get("content")
+	if _, ok := form["spoilerOf"]; ok {
+		spoilerOf := form.Get("spoilerOf")
+		in.SpoilerOf = &spoilerOf
+	}
+	in.NSFW, _ = strconv.ParseBool(form.Get("nsfw"))
+	in.Visibility = form.Get("visibility")
+	if _, ok := form["quotedPostID"]; ok {
+		quotedPostID := form.Get("quotedPostID")
+		in.QuotedPostID = &quotedPostID
+	}
+	return in
+}
+
 func (h *handler) posts(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	q := r.URL.Query()
 	last, _ := strconv.Atoi(q.Get("last"))
 	before := q.Get("before")
 	pp, err := h.Posts(ctx, way.Param(ctx, "username"), last, before)
-	if err == service.ErrInvalidUsername || err == service.ErrInvalidPostID {
+	if err == service.ErrInvalidUsername || err == service.ErrInvalidCursor {
 		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
 		return
 	}
 
+	if err == service.ErrPrivateAccount {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -83,6 +153,108 @@ func (h *handler) post(w http.ResponseWriter, r *http.Request) {
 	respond(w, p, http.StatusOK)
 }
 
+type updatePostInput struct {
+	Content   *string
+	SpoilerOf *string
+	NSFW      *bool
+}
+
+// 修改帖子，没有传的字段保持不变，spoilerOf 传空字符串表示去掉剧透提示
+func (h *handler) updatePost(w http.ResponseWriter, r *http.Request) {
+	var in updatePostInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ctx := r.Context()
+	postID := way.Param(ctx, "post_id")
+	p, err := h.UpdatePost(ctx, postID, in.Content, in.SpoilerOf, in.NSFW)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidPostID ||
+		err == service.ErrInvalidContent ||
+		err == service.ErrInvalidSpoiler {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrPermissionDenied {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, p, http.StatusOK)
+}
+
+func (h *handler) postRevisions(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	postID := way.Param(ctx, "post_id")
+	rr, err := h.PostRevisions(ctx, postID)
+	if err == service.ErrInvalidPostID {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, rr, http.StatusOK)
+}
+
+func (h *handler) deletePost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	postID := way.Param(ctx, "post_id")
+	err := h.DeletePost(ctx, postID)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidPostID {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrPermissionDenied {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 func (h *handler) togglePostLike(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	postID := way.Param(ctx, "post_id")
@@ -136,3 +308,62 @@ func (h *handler) togglePostSubscription(w http.ResponseWriter, r *http.Request)
 
 	respond(w, out, http.StatusOK)
 }
+
+func (h *handler) repost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	postID := way.Param(ctx, "post_id")
+	out, err := h.Repost(ctx, postID)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidPostID {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrForbiddenRepost || err == service.ErrUserBlocked {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, out, http.StatusOK)
+}
+
+func (h *handler) unrepost(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	postID := way.Param(ctx, "post_id")
+	out, err := h.Unrepost(ctx, postID)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidPostID {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrPostNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, out, http.StatusOK)
+}
@@ -0,0 +1,27 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/nicolasparada/nakama/internal/service"
+)
+
+// 全文搜索帖子和评论，q 支持 "短语"、from:username 和 #tag
+func (h *handler) searchPosts(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	first, _ := strconv.Atoi(q.Get("first"))
+	after := q.Get("after")
+	out, err := h.SearchPosts(r.Context(), q.Get("q"), first, after)
+	if err == service.ErrInvalidSearchQuery || err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, out, http.StatusOK)
+}
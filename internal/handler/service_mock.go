@@ -8,40 +8,81 @@ import (
 	"github.com/nicolasparada/nakama/internal/service"
 	"io"
 	"sync"
+	"time"
 )
 
 var (
+	lockServiceMockAcceptFollowRequest     sync.RWMutex
+	lockServiceMockAccountDeletion         sync.RWMutex
 	lockServiceMockAuthURI                 sync.RWMutex
 	lockServiceMockAuthUser                sync.RWMutex
 	lockServiceMockAuthUserIDFromToken     sync.RWMutex
+	lockServiceMockBlocks                  sync.RWMutex
+	lockServiceMockChangeUsername          sync.RWMutex
+	lockServiceMockCommentReplies          sync.RWMutex
 	lockServiceMockCommentStream           sync.RWMutex
 	lockServiceMockComments                sync.RWMutex
+	lockServiceMockConversation            sync.RWMutex
+	lockServiceMockConversations           sync.RWMutex
 	lockServiceMockCreateComment           sync.RWMutex
+	lockServiceMockCreateConversation      sync.RWMutex
 	lockServiceMockCreatePost              sync.RWMutex
 	lockServiceMockCreateUser              sync.RWMutex
+	lockServiceMockDeleteAccount           sync.RWMutex
+	lockServiceMockDeleteAvatar            sync.RWMutex
+	lockServiceMockDeleteComment           sync.RWMutex
+	lockServiceMockDeleteMute              sync.RWMutex
+	lockServiceMockDeletePost              sync.RWMutex
 	lockServiceMockDeleteTimelineItem      sync.RWMutex
 	lockServiceMockDevLogin                sync.RWMutex
+	lockServiceMockExport                  sync.RWMutex
+	lockServiceMockFollowRequests          sync.RWMutex
 	lockServiceMockFollowees               sync.RWMutex
 	lockServiceMockFollowers               sync.RWMutex
+	lockServiceMockHasUnreadMessages       sync.RWMutex
 	lockServiceMockHasUnreadNotifications  sync.RWMutex
+	lockServiceMockIdenticon               sync.RWMutex
+	lockServiceMockMarkConversationAsRead  sync.RWMutex
 	lockServiceMockMarkNotificationAsRead  sync.RWMutex
 	lockServiceMockMarkNotificationsAsRead sync.RWMutex
+	lockServiceMockMessageStream           sync.RWMutex
+	lockServiceMockMessages                sync.RWMutex
+	lockServiceMockMuteUser                sync.RWMutex
+	lockServiceMockMuteWord                sync.RWMutex
+	lockServiceMockMutes                   sync.RWMutex
 	lockServiceMockNotificationStream      sync.RWMutex
 	lockServiceMockNotifications           sync.RWMutex
 	lockServiceMockPost                    sync.RWMutex
+	lockServiceMockPostRevisions           sync.RWMutex
 	lockServiceMockPosts                   sync.RWMutex
+	lockServiceMockRankedTimeline          sync.RWMutex
+	lockServiceMockRejectFollowRequest     sync.RWMutex
+	lockServiceMockRenamedUsername         sync.RWMutex
+	lockServiceMockRepost                  sync.RWMutex
+	lockServiceMockSearchPosts             sync.RWMutex
 	lockServiceMockSendMagicLink           sync.RWMutex
+	lockServiceMockSendMessage             sync.RWMutex
+	lockServiceMockTagPosts                sync.RWMutex
 	lockServiceMockTimeline                sync.RWMutex
 	lockServiceMockTimelineItemStream      sync.RWMutex
+	lockServiceMockToggleBlock             sync.RWMutex
 	lockServiceMockToggleCommentLike       sync.RWMutex
 	lockServiceMockToggleFollow            sync.RWMutex
 	lockServiceMockTogglePostLike          sync.RWMutex
 	lockServiceMockTogglePostSubscription  sync.RWMutex
 	lockServiceMockToken                   sync.RWMutex
+	lockServiceMockTrendingTags            sync.RWMutex
+	lockServiceMockUnrepost                sync.RWMutex
 	lockServiceMockUpdateAvatar            sync.RWMutex
+	lockServiceMockUpdateComment           sync.RWMutex
+	lockServiceMockUpdateEmail             sync.RWMutex
+	lockServiceMockUpdateMute              sync.RWMutex
+	lockServiceMockUpdatePost              sync.RWMutex
+	lockServiceMockUpdateUser              sync.RWMutex
 	lockServiceMockUser                    sync.RWMutex
 	lockServiceMockUsernames               sync.RWMutex
 	lockServiceMockUsers                   sync.RWMutex
+	lockServiceMockVerifyEmail             sync.RWMutex
 )
 
 // Ensure, that ServiceMock does implement Service.
@@ -54,6 +95,12 @@ var _ Service = &ServiceMock{}
 //
 //         // make and configure a mocked Service
 //         mockedService := &ServiceMock{
+//             AcceptFollowRequestFunc: func(ctx context.Context, username string) error {
+// 	               panic("mock out the AcceptFollowRequest method")
+//             },
+//             AccountDeletionFunc: func(ctx context.Context) (service.AccountDeletion, error) {
+// 	               panic("mock out the AccountDeletion method")
+//             },
 //             AuthURIFunc: func(ctx context.Context, verificationCode string, redirectURI string) (string, error) {
 // 	               panic("mock out the AuthURI method")
 //             },
@@ -63,63 +110,153 @@ var _ Service = &ServiceMock{}
 //             AuthUserIDFromTokenFunc: func(token string) (string, error) {
 // 	               panic("mock out the AuthUserIDFromToken method")
 //             },
+//             BlocksFunc: func(ctx context.Context, first int, after string) ([]service.UserProfile, error) {
+// 	               panic("mock out the Blocks method")
+//             },
+//             ChangeUsernameFunc: func(ctx context.Context, username string) error {
+// 	               panic("mock out the ChangeUsername method")
+//             },
+//             CommentRepliesFunc: func(ctx context.Context, commentID string, last int, before string) (service.CommentsOutput, error) {
+// 	               panic("mock out the CommentReplies method")
+//             },
 //             CommentStreamFunc: func(ctx context.Context, postID string) (<-chan service.Comment, error) {
 // 	               panic("mock out the CommentStream method")
 //             },
-//             CommentsFunc: func(ctx context.Context, postID string, last int, before string) ([]service.Comment, error) {
+//             CommentsFunc: func(ctx context.Context, postID string, last int, before string) (service.CommentsOutput, error) {
 // 	               panic("mock out the Comments method")
 //             },
-//             CreateCommentFunc: func(ctx context.Context, postID string, content string) (service.Comment, error) {
+//             ConversationFunc: func(ctx context.Context, conversationID string) (service.Conversation, error) {
+// 	               panic("mock out the Conversation method")
+//             },
+//             ConversationsFunc: func(ctx context.Context, last int, before string) (service.ConversationsOutput, error) {
+// 	               panic("mock out the Conversations method")
+//             },
+//             CreateCommentFunc: func(ctx context.Context, postID string, content string, parentID *string) (service.Comment, error) {
 // 	               panic("mock out the CreateComment method")
 //             },
-//             CreatePostFunc: func(ctx context.Context, content string, spoilerOf *string, nsfw bool) (service.TimelineItem, error) {
+//             CreateConversationFunc: func(ctx context.Context, usernames []string) (service.Conversation, error) {
+// 	               panic("mock out the CreateConversation method")
+//             },
+//             CreatePostFunc: func(ctx context.Context, content string, spoilerOf *string, nsfw bool, visibility string, quotedPostID *string, media []io.Reader) (service.TimelineItem, error) {
 // 	               panic("mock out the CreatePost method")
 //             },
 //             CreateUserFunc: func(ctx context.Context, email string, username string) error {
 // 	               panic("mock out the CreateUser method")
 //             },
+//             DeleteAccountFunc: func(ctx context.Context) (service.AccountDeletion, error) {
+// 	               panic("mock out the DeleteAccount method")
+//             },
+//             DeleteAvatarFunc: func(ctx context.Context) error {
+// 	               panic("mock out the DeleteAvatar method")
+//             },
+//             DeleteCommentFunc: func(ctx context.Context, commentID string) error {
+// 	               panic("mock out the DeleteComment method")
+//             },
+//             DeleteMuteFunc: func(ctx context.Context, muteID string) error {
+// 	               panic("mock out the DeleteMute method")
+//             },
+//             DeletePostFunc: func(ctx context.Context, postID string) error {
+// 	               panic("mock out the DeletePost method")
+//             },
 //             DeleteTimelineItemFunc: func(ctx context.Context, timelineItemID string) error {
 // 	               panic("mock out the DeleteTimelineItem method")
 //             },
 //             DevLoginFunc: func(ctx context.Context, email string) (service.DevLoginOutput, error) {
 // 	               panic("mock out the DevLogin method")
 //             },
+//             ExportFunc: func(ctx context.Context) (io.ReadCloser, error) {
+// 	               panic("mock out the Export method")
+//             },
+//             FollowRequestsFunc: func(ctx context.Context, first int, after string) ([]service.UserProfile, error) {
+// 	               panic("mock out the FollowRequests method")
+//             },
 //             FolloweesFunc: func(ctx context.Context, username string, first int, after string) ([]service.UserProfile, error) {
 // 	               panic("mock out the Followees method")
 //             },
 //             FollowersFunc: func(ctx context.Context, username string, first int, after string) ([]service.UserProfile, error) {
 // 	               panic("mock out the Followers method")
 //             },
+//             HasUnreadMessagesFunc: func(ctx context.Context) (bool, error) {
+// 	               panic("mock out the HasUnreadMessages method")
+//             },
 //             HasUnreadNotificationsFunc: func(ctx context.Context) (bool, error) {
 // 	               panic("mock out the HasUnreadNotifications method")
 //             },
+//             IdenticonFunc: func(username string, size int) ([]byte, error) {
+// 	               panic("mock out the Identicon method")
+//             },
+//             MarkConversationAsReadFunc: func(ctx context.Context, conversationID string) error {
+// 	               panic("mock out the MarkConversationAsRead method")
+//             },
 //             MarkNotificationAsReadFunc: func(ctx context.Context, notificationID string) error {
 // 	               panic("mock out the MarkNotificationAsRead method")
 //             },
 //             MarkNotificationsAsReadFunc: func(ctx context.Context) error {
 // 	               panic("mock out the MarkNotificationsAsRead method")
 //             },
+//             MessageStreamFunc: func(ctx context.Context) (<-chan service.Message, error) {
+// 	               panic("mock out the MessageStream method")
+//             },
+//             MessagesFunc: func(ctx context.Context, conversationID string, last int, before string) (service.MessagesOutput, error) {
+// 	               panic("mock out the Messages method")
+//             },
+//             MuteUserFunc: func(ctx context.Context, username string, expiresAt *time.Time) (service.Mute, error) {
+// 	               panic("mock out the MuteUser method")
+//             },
+//             MuteWordFunc: func(ctx context.Context, word string, expiresAt *time.Time) (service.Mute, error) {
+// 	               panic("mock out the MuteWord method")
+//             },
+//             MutesFunc: func(ctx context.Context) ([]service.Mute, error) {
+// 	               panic("mock out the Mutes method")
+//             },
 //             NotificationStreamFunc: func(ctx context.Context) (<-chan service.Notification, error) {
 // 	               panic("mock out the NotificationStream method")
 //             },
-//             NotificationsFunc: func(ctx context.Context, last int, before string) ([]service.Notification, error) {
+//             NotificationsFunc: func(ctx context.Context, last int, before string) (service.NotificationsOutput, error) {
 // 	               panic("mock out the Notifications method")
 //             },
 //             PostFunc: func(ctx context.Context, postID string) (service.Post, error) {
 // 	               panic("mock out the Post method")
 //             },
-//             PostsFunc: func(ctx context.Context, username string, last int, before string) ([]service.Post, error) {
+//             PostRevisionsFunc: func(ctx context.Context, postID string) ([]service.PostRevision, error) {
+// 	               panic("mock out the PostRevisions method")
+//             },
+//             PostsFunc: func(ctx context.Context, username string, last int, before string) (service.PostsOutput, error) {
 // 	               panic("mock out the Posts method")
 //             },
+//             RankedTimelineFunc: func(ctx context.Context, first int, after string) (service.RankedTimelineOutput, error) {
+// 	               panic("mock out the RankedTimeline method")
+//             },
+//             RejectFollowRequestFunc: func(ctx context.Context, username string) error {
+// 	               panic("mock out the RejectFollowRequest method")
+//             },
+//             RenamedUsernameFunc: func(ctx context.Context, oldUsername string) (string, error) {
+// 	               panic("mock out the RenamedUsername method")
+//             },
+//             RepostFunc: func(ctx context.Context, postID string) (service.RepostOutput, error) {
+// 	               panic("mock out the Repost method")
+//             },
+//             SearchPostsFunc: func(ctx context.Context, q string, first int, after string) (service.SearchPostsOutput, error) {
+// 	               panic("mock out the SearchPosts method")
+//             },
 //             SendMagicLinkFunc: func(ctx context.Context, email string, redirectURI string) error {
 // 	               panic("mock out the SendMagicLink method")
 //             },
-//             TimelineFunc: func(ctx context.Context, last int, before string) ([]service.TimelineItem, error) {
+//             SendMessageFunc: func(ctx context.Context, conversationID string, content string) (service.Message, error) {
+// 	               panic("mock out the SendMessage method")
+//             },
+//             TagPostsFunc: func(ctx context.Context, tag string, last int, before string) (service.PostsOutput, error) {
+// 	               panic("mock out the TagPosts method")
+//             },
+//             TimelineFunc: func(ctx context.Context, last int, before string) (service.TimelineOutput, error) {
 // 	               panic("mock out the Timeline method")
 //             },
 //             TimelineItemStreamFunc: func(ctx context.Context) (<-chan service.TimelineItem, error) {
 // 	               panic("mock out the TimelineItemStream method")
 //             },
+//             ToggleBlockFunc: func(ctx context.Context, username string) (service.ToggleBlockOutput, error) {
+// 	               panic("mock out the ToggleBlock method")
+//             },
 //             ToggleCommentLikeFunc: func(ctx context.Context, commentID string) (service.ToggleLikeOutput, error) {
 // 	               panic("mock out the ToggleCommentLike method")
 //             },
@@ -135,18 +272,42 @@ var _ Service = &ServiceMock{}
 //             TokenFunc: func(ctx context.Context) (service.TokenOutput, error) {
 // 	               panic("mock out the Token method")
 //             },
+//             TrendingTagsFunc: func(ctx context.Context, window time.Duration, first int) ([]service.TrendingTag, error) {
+// 	               panic("mock out the TrendingTags method")
+//             },
+//             UnrepostFunc: func(ctx context.Context, postID string) (service.RepostOutput, error) {
+// 	               panic("mock out the Unrepost method")
+//             },
 //             UpdateAvatarFunc: func(ctx context.Context, r io.Reader) (string, error) {
 // 	               panic("mock out the UpdateAvatar method")
 //             },
+//             UpdateCommentFunc: func(ctx context.Context, commentID string, content string) (service.Comment, error) {
+// 	               panic("mock out the UpdateComment method")
+//             },
+//             UpdateEmailFunc: func(ctx context.Context, email string, redirectURI string) error {
+// 	               panic("mock out the UpdateEmail method")
+//             },
+//             UpdateMuteFunc: func(ctx context.Context, muteID string, expiresAt *time.Time) (service.Mute, error) {
+// 	               panic("mock out the UpdateMute method")
+//             },
+//             UpdatePostFunc: func(ctx context.Context, postID string, content *string, spoilerOf *string, nsfw *bool) (service.Post, error) {
+// 	               panic("mock out the UpdatePost method")
+//             },
+//             UpdateUserFunc: func(ctx context.Context, displayName *string, bio *string, location *string, website *string, private *bool) (service.UserProfile, error) {
+// 	               panic("mock out the UpdateUser method")
+//             },
 //             UserFunc: func(ctx context.Context, username string) (service.UserProfile, error) {
 // 	               panic("mock out the User method")
 //             },
-//             UsernamesFunc: func(ctx context.Context, startingWith string, first int, after string) ([]string, error) {
+//             UsernamesFunc: func(ctx context.Context, startingWith string, first int, after string) (service.UsernamesOutput, error) {
 // 	               panic("mock out the Usernames method")
 //             },
-//             UsersFunc: func(ctx context.Context, search string, first int, after string) ([]service.UserProfile, error) {
+//             UsersFunc: func(ctx context.Context, search string, first int, after string) (service.UsersOutput, error) {
 // 	               panic("mock out the Users method")
 //             },
+//             VerifyEmailFunc: func(ctx context.Context, verificationCode string, redirectURI string) (string, error) {
+// 	               panic("mock out the VerifyEmail method")
+//             },
 //         }
 //
 //         // use mockedService in code that requires Service
@@ -154,6 +315,12 @@ var _ Service = &ServiceMock{}
 //
 //     }
 type ServiceMock struct {
+	// AcceptFollowRequestFunc mocks the AcceptFollowRequest method.
+	AcceptFollowRequestFunc func(ctx context.Context, username string) error
+
+	// AccountDeletionFunc mocks the AccountDeletion method.
+	AccountDeletionFunc func(ctx context.Context) (service.AccountDeletion, error)
+
 	// AuthURIFunc mocks the AuthURI method.
 	AuthURIFunc func(ctx context.Context, verificationCode string, redirectURI string) (string, error)
 
@@ -163,63 +330,153 @@ type ServiceMock struct {
 	// AuthUserIDFromTokenFunc mocks the AuthUserIDFromToken method.
 	AuthUserIDFromTokenFunc func(token string) (string, error)
 
+	// BlocksFunc mocks the Blocks method.
+	BlocksFunc func(ctx context.Context, first int, after string) ([]service.UserProfile, error)
+
+	// ChangeUsernameFunc mocks the ChangeUsername method.
+	ChangeUsernameFunc func(ctx context.Context, username string) error
+
+	// CommentRepliesFunc mocks the CommentReplies method.
+	CommentRepliesFunc func(ctx context.Context, commentID string, last int, before string) (service.CommentsOutput, error)
+
 	// CommentStreamFunc mocks the CommentStream method.
 	CommentStreamFunc func(ctx context.Context, postID string) (<-chan service.Comment, error)
 
 	// CommentsFunc mocks the Comments method.
-	CommentsFunc func(ctx context.Context, postID string, last int, before string) ([]service.Comment, error)
+	CommentsFunc func(ctx context.Context, postID string, last int, before string) (service.CommentsOutput, error)
+
+	// ConversationFunc mocks the Conversation method.
+	ConversationFunc func(ctx context.Context, conversationID string) (service.Conversation, error)
+
+	// ConversationsFunc mocks the Conversations method.
+	ConversationsFunc func(ctx context.Context, last int, before string) (service.ConversationsOutput, error)
 
 	// CreateCommentFunc mocks the CreateComment method.
-	CreateCommentFunc func(ctx context.Context, postID string, content string) (service.Comment, error)
+	CreateCommentFunc func(ctx context.Context, postID string, content string, parentID *string) (service.Comment, error)
+
+	// CreateConversationFunc mocks the CreateConversation method.
+	CreateConversationFunc func(ctx context.Context, usernames []string) (service.Conversation, error)
 
 	// CreatePostFunc mocks the CreatePost method.
-	CreatePostFunc func(ctx context.Context, content string, spoilerOf *string, nsfw bool) (service.TimelineItem, error)
+	CreatePostFunc func(ctx context.Context, content string, spoilerOf *string, nsfw bool, visibility string, quotedPostID *string, media []io.Reader) (service.TimelineItem, error)
 
 	// CreateUserFunc mocks the CreateUser method.
 	CreateUserFunc func(ctx context.Context, email string, username string) error
 
+	// DeleteAccountFunc mocks the DeleteAccount method.
+	DeleteAccountFunc func(ctx context.Context) (service.AccountDeletion, error)
+
+	// DeleteAvatarFunc mocks the DeleteAvatar method.
+	DeleteAvatarFunc func(ctx context.Context) error
+
+	// DeleteCommentFunc mocks the DeleteComment method.
+	DeleteCommentFunc func(ctx context.Context, commentID string) error
+
+	// DeleteMuteFunc mocks the DeleteMute method.
+	DeleteMuteFunc func(ctx context.Context, muteID string) error
+
+	// DeletePostFunc mocks the DeletePost method.
+	DeletePostFunc func(ctx context.Context, postID string) error
+
 	// DeleteTimelineItemFunc mocks the DeleteTimelineItem method.
 	DeleteTimelineItemFunc func(ctx context.Context, timelineItemID string) error
 
 	// DevLoginFunc mocks the DevLogin method.
 	DevLoginFunc func(ctx context.Context, email string) (service.DevLoginOutput, error)
 
+	// ExportFunc mocks the Export method.
+	ExportFunc func(ctx context.Context) (io.ReadCloser, error)
+
+	// FollowRequestsFunc mocks the FollowRequests method.
+	FollowRequestsFunc func(ctx context.Context, first int, after string) ([]service.UserProfile, error)
+
 	// FolloweesFunc mocks the Followees method.
 	FolloweesFunc func(ctx context.Context, username string, first int, after string) ([]service.UserProfile, error)
 
 	// FollowersFunc mocks the Followers method.
 	FollowersFunc func(ctx context.Context, username string, first int, after string) ([]service.UserProfile, error)
 
+	// HasUnreadMessagesFunc mocks the HasUnreadMessages method.
+	HasUnreadMessagesFunc func(ctx context.Context) (bool, error)
+
 	// HasUnreadNotificationsFunc mocks the HasUnreadNotifications method.
 	HasUnreadNotificationsFunc func(ctx context.Context) (bool, error)
 
+	// IdenticonFunc mocks the Identicon method.
+	IdenticonFunc func(username string, size int) ([]byte, error)
+
+	// MarkConversationAsReadFunc mocks the MarkConversationAsRead method.
+	MarkConversationAsReadFunc func(ctx context.Context, conversationID string) error
+
 	// MarkNotificationAsReadFunc mocks the MarkNotificationAsRead method.
 	MarkNotificationAsReadFunc func(ctx context.Context, notificationID string) error
 
 	// MarkNotificationsAsReadFunc mocks the MarkNotificationsAsRead method.
 	MarkNotificationsAsReadFunc func(ctx context.Context) error
 
+	// MessageStreamFunc mocks the MessageStream method.
+	MessageStreamFunc func(ctx context.Context) (<-chan service.Message, error)
+
+	// MessagesFunc mocks the Messages method.
+	MessagesFunc func(ctx context.Context, conversationID string, last int, before string) (service.MessagesOutput, error)
+
+	// MuteUserFunc mocks the MuteUser method.
+	MuteUserFunc func(ctx context.Context, username string, expiresAt *time.Time) (service.Mute, error)
+
+	// MuteWordFunc mocks the MuteWord method.
+	MuteWordFunc func(ctx context.Context, word string, expiresAt *time.Time) (service.Mute, error)
+
+	// MutesFunc mocks the Mutes method.
+	MutesFunc func(ctx context.Context) ([]service.Mute, error)
+
 	// NotificationStreamFunc mocks the NotificationStream method.
 	NotificationStreamFunc func(ctx context.Context) (<-chan service.Notification, error)
 
 	// NotificationsFunc mocks the Notifications method.
-	NotificationsFunc func(ctx context.Context, last int, before string) ([]service.Notification, error)
+	NotificationsFunc func(ctx context.Context, last int, before string) (service.NotificationsOutput, error)
 
 	// PostFunc mocks the Post method.
 	PostFunc func(ctx context.Context, postID string) (service.Post, error)
 
+	// PostRevisionsFunc mocks the PostRevisions method.
+	PostRevisionsFunc func(ctx context.Context, postID string) ([]service.PostRevision, error)
+
 	// PostsFunc mocks the Posts method.
-	PostsFunc func(ctx context.Context, username string, last int, before string) ([]service.Post, error)
+	PostsFunc func(ctx context.Context, username string, last int, before string) (service.PostsOutput, error)
+
+	// RankedTimelineFunc mocks the RankedTimeline method.
+	RankedTimelineFunc func(ctx context.Context, first int, after string) (service.RankedTimelineOutput, error)
+
+	// RejectFollowRequestFunc mocks the RejectFollowRequest method.
+	RejectFollowRequestFunc func(ctx context.Context, username string) error
+
+	// RenamedUsernameFunc mocks the RenamedUsername method.
+	RenamedUsernameFunc func(ctx context.Context, oldUsername string) (string, error)
+
+	// RepostFunc mocks the Repost method.
+	RepostFunc func(ctx context.Context, postID string) (service.RepostOutput, error)
+
+	// SearchPostsFunc mocks the SearchPosts method.
+	SearchPostsFunc func(ctx context.Context, q string, first int, after string) (service.SearchPostsOutput, error)
 
 	// SendMagicLinkFunc mocks the SendMagicLink method.
 	SendMagicLinkFunc func(ctx context.Context, email string, redirectURI string) error
 
+	// SendMessageFunc mocks the SendMessage method.
+	SendMessageFunc func(ctx context.Context, conversationID string, content string) (service.Message, error)
+
+	// TagPostsFunc mocks the TagPosts method.
+	TagPostsFunc func(ctx context.Context, tag string, last int, before string) (service.PostsOutput, error)
+
 	// TimelineFunc mocks the Timeline method.
-	TimelineFunc func(ctx context.Context, last int, before string) ([]service.TimelineItem, error)
+	TimelineFunc func(ctx context.Context, last int, before string) (service.TimelineOutput, error)
 
 	// TimelineItemStreamFunc mocks the TimelineItemStream method.
 	TimelineItemStreamFunc func(ctx context.Context) (<-chan service.TimelineItem, error)
 
+	// ToggleBlockFunc mocks the ToggleBlock method.
+	ToggleBlockFunc func(ctx context.Context, username string) (service.ToggleBlockOutput, error)
+
 	// ToggleCommentLikeFunc mocks the ToggleCommentLike method.
 	ToggleCommentLikeFunc func(ctx context.Context, commentID string) (service.ToggleLikeOutput, error)
 
@@ -235,20 +492,56 @@ type ServiceMock struct {
 	// TokenFunc mocks the Token method.
 	TokenFunc func(ctx context.Context) (service.TokenOutput, error)
 
+	// TrendingTagsFunc mocks the TrendingTags method.
+	TrendingTagsFunc func(ctx context.Context, window time.Duration, first int) ([]service.TrendingTag, error)
+
+	// UnrepostFunc mocks the Unrepost method.
+	UnrepostFunc func(ctx context.Context, postID string) (service.RepostOutput, error)
+
 	// UpdateAvatarFunc mocks the UpdateAvatar method.
 	UpdateAvatarFunc func(ctx context.Context, r io.Reader) (string, error)
 
+	// UpdateCommentFunc mocks the UpdateComment method.
+	UpdateCommentFunc func(ctx context.Context, commentID string, content string) (service.Comment, error)
+
+	// UpdateEmailFunc mocks the UpdateEmail method.
+	UpdateEmailFunc func(ctx context.Context, email string, redirectURI string) error
+
+	// UpdateMuteFunc mocks the UpdateMute method.
+	UpdateMuteFunc func(ctx context.Context, muteID string, expiresAt *time.Time) (service.Mute, error)
+
+	// UpdatePostFunc mocks the UpdatePost method.
+	UpdatePostFunc func(ctx context.Context, postID string, content *string, spoilerOf *string, nsfw *bool) (service.Post, error)
+
+	// UpdateUserFunc mocks the UpdateUser method.
+	UpdateUserFunc func(ctx context.Context, displayName *string, bio *string, location *string, website *string, private *bool) (service.UserProfile, error)
+
 	// UserFunc mocks the User method.
 	UserFunc func(ctx context.Context, username string) (service.UserProfile, error)
 
 	// UsernamesFunc mocks the Usernames method.
-	UsernamesFunc func(ctx context.Context, startingWith string, first int, after string) ([]string, error)
+	UsernamesFunc func(ctx context.Context, startingWith string, first int, after string) (service.UsernamesOutput, error)
 
 	// UsersFunc mocks the Users method.
-	UsersFunc func(ctx context.Context, search string, first int, after string) ([]service.UserProfile, error)
+	UsersFunc func(ctx context.Context, search string, first int, after string) (service.UsersOutput, error)
+
+	// VerifyEmailFunc mocks the VerifyEmail method.
+	VerifyEmailFunc func(ctx context.Context, verificationCode string, redirectURI string) (string, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// AcceptFollowRequest holds details about calls to the AcceptFollowRequest method.
+		AcceptFollowRequest []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Username is the username argument value.
+			Username string
+		}
+		// AccountDeletion holds details about calls to the AccountDeletion method.
+		AccountDeletion []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
 		// AuthURI holds details about calls to the AuthURI method.
 		AuthURI []struct {
 			// Ctx is the ctx argument value.
@@ -268,6 +561,33 @@ type ServiceMock struct {
 			// Token is the token argument value.
 			Token string
 		}
+		// Blocks holds details about calls to the Blocks method.
+		Blocks []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// First is the first argument value.
+			First int
+			// After is the after argument value.
+			After string
+		}
+		// ChangeUsername holds details about calls to the ChangeUsername method.
+		ChangeUsername []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Username is the username argument value.
+			Username string
+		}
+		// CommentReplies holds details about calls to the CommentReplies method.
+		CommentReplies []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CommentID is the commentID argument value.
+			CommentID string
+			// Last is the last argument value.
+			Last int
+			// Before is the before argument value.
+			Before string
+		}
 		// CommentStream holds details about calls to the CommentStream method.
 		CommentStream []struct {
 			// Ctx is the ctx argument value.
@@ -286,6 +606,22 @@ type ServiceMock struct {
 			// Before is the before argument value.
 			Before string
 		}
+		// Conversation holds details about calls to the Conversation method.
+		Conversation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+		}
+		// Conversations holds details about calls to the Conversations method.
+		Conversations []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Last is the last argument value.
+			Last int
+			// Before is the before argument value.
+			Before string
+		}
 		// CreateComment holds details about calls to the CreateComment method.
 		CreateComment []struct {
 			// Ctx is the ctx argument value.
@@ -294,6 +630,15 @@ type ServiceMock struct {
 			PostID string
 			// Content is the content argument value.
 			Content string
+			// ParentID is the parentID argument value.
+			ParentID *string
+		}
+		// CreateConversation holds details about calls to the CreateConversation method.
+		CreateConversation []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Usernames is the usernames argument value.
+			Usernames []string
 		}
 		// CreatePost holds details about calls to the CreatePost method.
 		CreatePost []struct {
@@ -305,6 +650,12 @@ type ServiceMock struct {
 			SpoilerOf *string
 			// Nsfw is the nsfw argument value.
 			Nsfw bool
+			// Visibility is the visibility argument value.
+			Visibility string
+			// QuotedPostID is the quotedPostID argument value.
+			QuotedPostID *string
+			// Media is the media argument value.
+			Media []io.Reader
 		}
 		// CreateUser holds details about calls to the CreateUser method.
 		CreateUser []struct {
@@ -315,6 +666,37 @@ type ServiceMock struct {
 			// Username is the username argument value.
 			Username string
 		}
+		// DeleteAccount holds details about calls to the DeleteAccount method.
+		DeleteAccount []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// DeleteAvatar holds details about calls to the DeleteAvatar method.
+		DeleteAvatar []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// DeleteComment holds details about calls to the DeleteComment method.
+		DeleteComment []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// CommentID is the commentID argument value.
+			CommentID string
+		}
+		// DeleteMute holds details about calls to the DeleteMute method.
+		DeleteMute []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// MuteID is the muteID argument value.
+			MuteID string
+		}
+		// DeletePost holds details about calls to the DeletePost method.
+		DeletePost []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PostID is the postID argument value.
+			PostID string
+		}
 		// DeleteTimelineItem holds details about calls to the DeleteTimelineItem method.
 		DeleteTimelineItem []struct {
 			// Ctx is the ctx argument value.
@@ -329,6 +711,20 @@ type ServiceMock struct {
 			// Email is the email argument value.
 			Email string
 		}
+		// Export holds details about calls to the Export method.
+		Export []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// FollowRequests holds details about calls to the FollowRequests method.
+		FollowRequests []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// First is the first argument value.
+			First int
+			// After is the after argument value.
+			After string
+		}
 		// Followees holds details about calls to the Followees method.
 		Followees []struct {
 			// Ctx is the ctx argument value.
@@ -351,11 +747,30 @@ type ServiceMock struct {
 			// After is the after argument value.
 			After string
 		}
+		// HasUnreadMessages holds details about calls to the HasUnreadMessages method.
+		HasUnreadMessages []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
 		// HasUnreadNotifications holds details about calls to the HasUnreadNotifications method.
 		HasUnreadNotifications []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// Identicon holds details about calls to the Identicon method.
+		Identicon []struct {
+			// Username is the username argument value.
+			Username string
+			// Size is the size argument value.
+			Size int
+		}
+		// MarkConversationAsRead holds details about calls to the MarkConversationAsRead method.
+		MarkConversationAsRead []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+		}
 		// MarkNotificationAsRead holds details about calls to the MarkNotificationAsRead method.
 		MarkNotificationAsRead []struct {
 			// Ctx is the ctx argument value.
@@ -368,6 +783,45 @@ type ServiceMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// MessageStream holds details about calls to the MessageStream method.
+		MessageStream []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// Messages holds details about calls to the Messages method.
+		Messages []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+			// Last is the last argument value.
+			Last int
+			// Before is the before argument value.
+			Before string
+		}
+		// MuteUser holds details about calls to the MuteUser method.
+		MuteUser []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Username is the username argument value.
+			Username string
+			// ExpiresAt is the expiresAt argument value.
+			ExpiresAt *time.Time
+		}
+		// MuteWord holds details about calls to the MuteWord method.
+		MuteWord []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Word is the word argument value.
+			Word string
+			// ExpiresAt is the expiresAt argument value.
+			ExpiresAt *time.Time
+		}
+		// Mutes holds details about calls to the Mutes method.
+		Mutes []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
 		// NotificationStream holds details about calls to the NotificationStream method.
 		NotificationStream []struct {
 			// Ctx is the ctx argument value.
@@ -389,6 +843,13 @@ type ServiceMock struct {
 			// PostID is the postID argument value.
 			PostID string
 		}
+		// PostRevisions holds details about calls to the PostRevisions method.
+		PostRevisions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PostID is the postID argument value.
+			PostID string
+		}
 		// Posts holds details about calls to the Posts method.
 		Posts []struct {
 			// Ctx is the ctx argument value.
@@ -400,6 +861,47 @@ type ServiceMock struct {
 			// Before is the before argument value.
 			Before string
 		}
+		// RankedTimeline holds details about calls to the RankedTimeline method.
+		RankedTimeline []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// First is the first argument value.
+			First int
+			// After is the after argument value.
+			After string
+		}
+		// RejectFollowRequest holds details about calls to the RejectFollowRequest method.
+		RejectFollowRequest []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Username is the username argument value.
+			Username string
+		}
+		// RenamedUsername holds details about calls to the RenamedUsername method.
+		RenamedUsername []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// OldUsername is the oldUsername argument value.
+			OldUsername string
+		}
+		// Repost holds details about calls to the Repost method.
+		Repost []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PostID is the postID argument value.
+			PostID string
+		}
+		// SearchPosts holds details about calls to the SearchPosts method.
+		SearchPosts []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Q is the q argument value.
+			Q string
+			// First is the first argument value.
+			First int
+			// After is the after argument value.
+			After string
+		}
 		// SendMagicLink holds details about calls to the SendMagicLink method.
 		SendMagicLink []struct {
 			// Ctx is the ctx argument value.
@@ -409,6 +911,26 @@ type ServiceMock struct {
 			// RedirectURI is the redirectURI argument value.
 			RedirectURI string
 		}
+		// SendMessage holds details about calls to the SendMessage method.
+		SendMessage []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// ConversationID is the conversationID argument value.
+			ConversationID string
+			// Content is the content argument value.
+			Content string
+		}
+		// TagPosts holds details about calls to the TagPosts method.
+		TagPosts []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Tag is the tag argument value.
+			Tag string
+			// Last is the last argument value.
+			Last int
+			// Before is the before argument value.
+			Before string
+		}
 		// Timeline holds details about calls to the Timeline method.
 		Timeline []struct {
 			// Ctx is the ctx argument value.
@@ -423,6 +945,13 @@ type ServiceMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// ToggleBlock holds details about calls to the ToggleBlock method.
+		ToggleBlock []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Username is the username argument value.
+			Username string
+		}
 		// ToggleCommentLike holds details about calls to the ToggleCommentLike method.
 		ToggleCommentLike []struct {
 			// Ctx is the ctx argument value.
@@ -456,6 +985,22 @@ type ServiceMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// TrendingTags holds details about calls to the TrendingTags method.
+		TrendingTags []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Window is the window argument value.
+			Window time.Duration
+			// First is the first argument value.
+			First int
+		}
+		// Unrepost holds details about calls to the Unrepost method.
+		Unrepost []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PostID is the postID argument value.
+			PostID string
+		}
 		// UpdateAvatar holds details about calls to the UpdateAvatar method.
 		UpdateAvatar []struct {
 			// Ctx is the ctx argument value.
@@ -463,23 +1008,78 @@ type ServiceMock struct {
 			// R is the r argument value.
 			R io.Reader
 		}
-		// User holds details about calls to the User method.
-		User []struct {
+		// UpdateComment holds details about calls to the UpdateComment method.
+		UpdateComment []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// Username is the username argument value.
-			Username string
+			// CommentID is the commentID argument value.
+			CommentID string
+			// Content is the content argument value.
+			Content string
 		}
-		// Usernames holds details about calls to the Usernames method.
-		Usernames []struct {
+		// UpdateEmail holds details about calls to the UpdateEmail method.
+		UpdateEmail []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// StartingWith is the startingWith argument value.
-			StartingWith string
-			// First is the first argument value.
-			First int
-			// After is the after argument value.
-			After string
+			// Email is the email argument value.
+			Email string
+			// RedirectURI is the redirectURI argument value.
+			RedirectURI string
+		}
+		// UpdateMute holds details about calls to the UpdateMute method.
+		UpdateMute []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// MuteID is the muteID argument value.
+			MuteID string
+			// ExpiresAt is the expiresAt argument value.
+			ExpiresAt *time.Time
+		}
+		// UpdatePost holds details about calls to the UpdatePost method.
+		UpdatePost []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// PostID is the postID argument value.
+			PostID string
+			// Content is the content argument value.
+			Content *string
+			// SpoilerOf is the spoilerOf argument value.
+			SpoilerOf *string
+			// Nsfw is the nsfw argument value.
+			Nsfw *bool
+		}
+		// UpdateUser holds details about calls to the UpdateUser method.
+		UpdateUser []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DisplayName is the displayName argument value.
+			DisplayName *string
+			// Bio is the bio argument value.
+			Bio *string
+			// Location is the location argument value.
+			Location *string
+			// Website is the website argument value.
+			Website *string
+			// Private is the private argument value.
+			Private *bool
+		}
+		// User holds details about calls to the User method.
+		User []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Username is the username argument value.
+			Username string
+		}
+		// Usernames holds details about calls to the Usernames method.
+		Usernames []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// StartingWith is the startingWith argument value.
+			StartingWith string
+			// First is the first argument value.
+			First int
+			// After is the after argument value.
+			After string
 		}
 		// Users holds details about calls to the Users method.
 		Users []struct {
@@ -492,9 +1092,84 @@ type ServiceMock struct {
 			// After is the after argument value.
 			After string
 		}
+		// VerifyEmail holds details about calls to the VerifyEmail method.
+		VerifyEmail []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// VerificationCode is the verificationCode argument value.
+			VerificationCode string
+			// RedirectURI is the redirectURI argument value.
+			RedirectURI string
+		}
 	}
 }
 
+// AcceptFollowRequest calls AcceptFollowRequestFunc.
+func (mock *ServiceMock) AcceptFollowRequest(ctx context.Context, username string) error {
+	if mock.AcceptFollowRequestFunc == nil {
+		panic("ServiceMock.AcceptFollowRequestFunc: method is nil but Service.AcceptFollowRequest was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Username string
+	}{
+		Ctx:      ctx,
+		Username: username,
+	}
+	lockServiceMockAcceptFollowRequest.Lock()
+	mock.calls.AcceptFollowRequest = append(mock.calls.AcceptFollowRequest, callInfo)
+	lockServiceMockAcceptFollowRequest.Unlock()
+	return mock.AcceptFollowRequestFunc(ctx, username)
+}
+
+// AcceptFollowRequestCalls gets all the calls that were made to AcceptFollowRequest.
+// Check the length with:
+//     len(mockedService.AcceptFollowRequestCalls())
+func (mock *ServiceMock) AcceptFollowRequestCalls() []struct {
+	Ctx      context.Context
+	Username string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Username string
+	}
+	lockServiceMockAcceptFollowRequest.RLock()
+	calls = mock.calls.AcceptFollowRequest
+	lockServiceMockAcceptFollowRequest.RUnlock()
+	return calls
+}
+
+// AccountDeletion calls AccountDeletionFunc.
+func (mock *ServiceMock) AccountDeletion(ctx context.Context) (service.AccountDeletion, error) {
+	if mock.AccountDeletionFunc == nil {
+		panic("ServiceMock.AccountDeletionFunc: method is nil but Service.AccountDeletion was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	lockServiceMockAccountDeletion.Lock()
+	mock.calls.AccountDeletion = append(mock.calls.AccountDeletion, callInfo)
+	lockServiceMockAccountDeletion.Unlock()
+	return mock.AccountDeletionFunc(ctx)
+}
+
+// AccountDeletionCalls gets all the calls that were made to AccountDeletion.
+// Check the length with:
+//     len(mockedService.AccountDeletionCalls())
+func (mock *ServiceMock) AccountDeletionCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	lockServiceMockAccountDeletion.RLock()
+	calls = mock.calls.AccountDeletion
+	lockServiceMockAccountDeletion.RUnlock()
+	return calls
+}
+
 // AuthURI calls AuthURIFunc.
 func (mock *ServiceMock) AuthURI(ctx context.Context, verificationCode string, redirectURI string) (string, error) {
 	if mock.AuthURIFunc == nil {
@@ -596,6 +1271,123 @@ func (mock *ServiceMock) AuthUserIDFromTokenCalls() []struct {
 	return calls
 }
 
+// Blocks calls BlocksFunc.
+func (mock *ServiceMock) Blocks(ctx context.Context, first int, after string) ([]service.UserProfile, error) {
+	if mock.BlocksFunc == nil {
+		panic("ServiceMock.BlocksFunc: method is nil but Service.Blocks was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		First int
+		After string
+	}{
+		Ctx:   ctx,
+		First: first,
+		After: after,
+	}
+	lockServiceMockBlocks.Lock()
+	mock.calls.Blocks = append(mock.calls.Blocks, callInfo)
+	lockServiceMockBlocks.Unlock()
+	return mock.BlocksFunc(ctx, first, after)
+}
+
+// BlocksCalls gets all the calls that were made to Blocks.
+// Check the length with:
+//     len(mockedService.BlocksCalls())
+func (mock *ServiceMock) BlocksCalls() []struct {
+	Ctx   context.Context
+	First int
+	After string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		First int
+		After string
+	}
+	lockServiceMockBlocks.RLock()
+	calls = mock.calls.Blocks
+	lockServiceMockBlocks.RUnlock()
+	return calls
+}
+
+// ChangeUsername calls ChangeUsernameFunc.
+func (mock *ServiceMock) ChangeUsername(ctx context.Context, username string) error {
+	if mock.ChangeUsernameFunc == nil {
+		panic("ServiceMock.ChangeUsernameFunc: method is nil but Service.ChangeUsername was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Username string
+	}{
+		Ctx:      ctx,
+		Username: username,
+	}
+	lockServiceMockChangeUsername.Lock()
+	mock.calls.ChangeUsername = append(mock.calls.ChangeUsername, callInfo)
+	lockServiceMockChangeUsername.Unlock()
+	return mock.ChangeUsernameFunc(ctx, username)
+}
+
+// ChangeUsernameCalls gets all the calls that were made to ChangeUsername.
+// Check the length with:
+//     len(mockedService.ChangeUsernameCalls())
+func (mock *ServiceMock) ChangeUsernameCalls() []struct {
+	Ctx      context.Context
+	Username string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Username string
+	}
+	lockServiceMockChangeUsername.RLock()
+	calls = mock.calls.ChangeUsername
+	lockServiceMockChangeUsername.RUnlock()
+	return calls
+}
+
+// CommentReplies calls CommentRepliesFunc.
+func (mock *ServiceMock) CommentReplies(ctx context.Context, commentID string, last int, before string) (service.CommentsOutput, error) {
+	if mock.CommentRepliesFunc == nil {
+		panic("ServiceMock.CommentRepliesFunc: method is nil but Service.CommentReplies was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID string
+		Last      int
+		Before    string
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+		Last:      last,
+		Before:    before,
+	}
+	lockServiceMockCommentReplies.Lock()
+	mock.calls.CommentReplies = append(mock.calls.CommentReplies, callInfo)
+	lockServiceMockCommentReplies.Unlock()
+	return mock.CommentRepliesFunc(ctx, commentID, last, before)
+}
+
+// CommentRepliesCalls gets all the calls that were made to CommentReplies.
+// Check the length with:
+//     len(mockedService.CommentRepliesCalls())
+func (mock *ServiceMock) CommentRepliesCalls() []struct {
+	Ctx       context.Context
+	CommentID string
+	Last      int
+	Before    string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID string
+		Last      int
+		Before    string
+	}
+	lockServiceMockCommentReplies.RLock()
+	calls = mock.calls.CommentReplies
+	lockServiceMockCommentReplies.RUnlock()
+	return calls
+}
+
 // CommentStream calls CommentStreamFunc.
 func (mock *ServiceMock) CommentStream(ctx context.Context, postID string) (<-chan service.Comment, error) {
 	if mock.CommentStreamFunc == nil {
@@ -632,7 +1424,7 @@ func (mock *ServiceMock) CommentStreamCalls() []struct {
 }
 
 // Comments calls CommentsFunc.
-func (mock *ServiceMock) Comments(ctx context.Context, postID string, last int, before string) ([]service.Comment, error) {
+func (mock *ServiceMock) Comments(ctx context.Context, postID string, last int, before string) (service.CommentsOutput, error) {
 	if mock.CommentsFunc == nil {
 		panic("ServiceMock.CommentsFunc: method is nil but Service.Comments was just called")
 	}
@@ -674,38 +1466,116 @@ func (mock *ServiceMock) CommentsCalls() []struct {
 	return calls
 }
 
+// Conversation calls ConversationFunc.
+func (mock *ServiceMock) Conversation(ctx context.Context, conversationID string) (service.Conversation, error) {
+	if mock.ConversationFunc == nil {
+		panic("ServiceMock.ConversationFunc: method is nil but Service.Conversation was just called")
+	}
+	callInfo := struct {
+		Ctx            context.Context
+		ConversationID string
+	}{
+		Ctx:            ctx,
+		ConversationID: conversationID,
+	}
+	lockServiceMockConversation.Lock()
+	mock.calls.Conversation = append(mock.calls.Conversation, callInfo)
+	lockServiceMockConversation.Unlock()
+	return mock.ConversationFunc(ctx, conversationID)
+}
+
+// ConversationCalls gets all the calls that were made to Conversation.
+// Check the length with:
+//     len(mockedService.ConversationCalls())
+func (mock *ServiceMock) ConversationCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		ConversationID string
+	}
+	lockServiceMockConversation.RLock()
+	calls = mock.calls.Conversation
+	lockServiceMockConversation.RUnlock()
+	return calls
+}
+
+// Conversations calls ConversationsFunc.
+func (mock *ServiceMock) Conversations(ctx context.Context, last int, before string) (service.ConversationsOutput, error) {
+	if mock.ConversationsFunc == nil {
+		panic("ServiceMock.ConversationsFunc: method is nil but Service.Conversations was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Last   int
+		Before string
+	}{
+		Ctx:    ctx,
+		Last:   last,
+		Before: before,
+	}
+	lockServiceMockConversations.Lock()
+	mock.calls.Conversations = append(mock.calls.Conversations, callInfo)
+	lockServiceMockConversations.Unlock()
+	return mock.ConversationsFunc(ctx, last, before)
+}
+
+// ConversationsCalls gets all the calls that were made to Conversations.
+// Check the length with:
+//     len(mockedService.ConversationsCalls())
+func (mock *ServiceMock) ConversationsCalls() []struct {
+	Ctx    context.Context
+	Last   int
+	Before string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Last   int
+		Before string
+	}
+	lockServiceMockConversations.RLock()
+	calls = mock.calls.Conversations
+	lockServiceMockConversations.RUnlock()
+	return calls
+}
+
 // CreateComment calls CreateCommentFunc.
-func (mock *ServiceMock) CreateComment(ctx context.Context, postID string, content string) (service.Comment, error) {
+func (mock *ServiceMock) CreateComment(ctx context.Context, postID string, content string, parentID *string) (service.Comment, error) {
 	if mock.CreateCommentFunc == nil {
 		panic("ServiceMock.CreateCommentFunc: method is nil but Service.CreateComment was just called")
 	}
 	callInfo := struct {
-		Ctx     context.Context
-		PostID  string
-		Content string
+		Ctx      context.Context
+		PostID   string
+		Content  string
+		ParentID *string
 	}{
-		Ctx:     ctx,
-		PostID:  postID,
-		Content: content,
+		Ctx:      ctx,
+		PostID:   postID,
+		Content:  content,
+		ParentID: parentID,
 	}
 	lockServiceMockCreateComment.Lock()
 	mock.calls.CreateComment = append(mock.calls.CreateComment, callInfo)
 	lockServiceMockCreateComment.Unlock()
-	return mock.CreateCommentFunc(ctx, postID, content)
+	return mock.CreateCommentFunc(ctx, postID, content, parentID)
 }
 
 // CreateCommentCalls gets all the calls that were made to CreateComment.
 // Check the length with:
 //     len(mockedService.CreateCommentCalls())
 func (mock *ServiceMock) CreateCommentCalls() []struct {
-	Ctx     context.Context
-	PostID  string
-	Content string
+	Ctx      context.Context
+	PostID   string
+	Content  string
+	ParentID *string
 } {
 	var calls []struct {
-		Ctx     context.Context
-		PostID  string
-		Content string
+		Ctx      context.Context
+		PostID   string
+		Content  string
+		ParentID *string
 	}
 	lockServiceMockCreateComment.RLock()
 	calls = mock.calls.CreateComment
@@ -713,42 +1583,89 @@ func (mock *ServiceMock) CreateCommentCalls() []struct {
 	return calls
 }
 
+// CreateConversation calls CreateConversationFunc.
+func (mock *ServiceMock) CreateConversation(ctx context.Context, usernames []string) (service.Conversation, error) {
+	if mock.CreateConversationFunc == nil {
+		panic("ServiceMock.CreateConversationFunc: method is nil but Service.CreateConversation was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Usernames []string
+	}{
+		Ctx:       ctx,
+		Usernames: usernames,
+	}
+	lockServiceMockCreateConversation.Lock()
+	mock.calls.CreateConversation = append(mock.calls.CreateConversation, callInfo)
+	lockServiceMockCreateConversation.Unlock()
+	return mock.CreateConversationFunc(ctx, usernames)
+}
+
+// CreateConversationCalls gets all the calls that were made to CreateConversation.
+// Check the length with:
+//     len(mockedService.CreateConversationCalls())
+func (mock *ServiceMock) CreateConversationCalls() []struct {
+	Ctx       context.Context
+	Usernames []string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Usernames []string
+	}
+	lockServiceMockCreateConversation.RLock()
+	calls = mock.calls.CreateConversation
+	lockServiceMockCreateConversation.RUnlock()
+	return calls
+}
+
 // CreatePost calls CreatePostFunc.
-func (mock *ServiceMock) CreatePost(ctx context.Context, content string, spoilerOf *string, nsfw bool) (service.TimelineItem, error) {
+func (mock *ServiceMock) CreatePost(ctx context.Context, content string, spoilerOf *string, nsfw bool, visibility string, quotedPostID *string, media []io.Reader) (service.TimelineItem, error) {
 	if mock.CreatePostFunc == nil {
 		panic("ServiceMock.CreatePostFunc: method is nil but Service.CreatePost was just called")
 	}
 	callInfo := struct {
-		Ctx       context.Context
-		Content   string
-		SpoilerOf *string
-		Nsfw      bool
+		Ctx          context.Context
+		Content      string
+		SpoilerOf    *string
+		Nsfw         bool
+		Visibility   string
+		QuotedPostID *string
+		Media        []io.Reader
 	}{
-		Ctx:       ctx,
-		Content:   content,
-		SpoilerOf: spoilerOf,
-		Nsfw:      nsfw,
+		Ctx:          ctx,
+		Content:      content,
+		SpoilerOf:    spoilerOf,
+		Nsfw:         nsfw,
+		Visibility:   visibility,
+		QuotedPostID: quotedPostID,
+		Media:        media,
 	}
 	lockServiceMockCreatePost.Lock()
 	mock.calls.CreatePost = append(mock.calls.CreatePost, callInfo)
 	lockServiceMockCreatePost.Unlock()
-	return mock.CreatePostFunc(ctx, content, spoilerOf, nsfw)
+	return mock.CreatePostFunc(ctx, content, spoilerOf, nsfw, visibility, quotedPostID, media)
 }
 
 // CreatePostCalls gets all the calls that were made to CreatePost.
 // Check the length with:
 //     len(mockedService.CreatePostCalls())
 func (mock *ServiceMock) CreatePostCalls() []struct {
-	Ctx       context.Context
-	Content   string
-	SpoilerOf *string
-	Nsfw      bool
+	Ctx          context.Context
+	Content      string
+	SpoilerOf    *string
+	Nsfw         bool
+	Visibility   string
+	QuotedPostID *string
+	Media        []io.Reader
 } {
 	var calls []struct {
-		Ctx       context.Context
-		Content   string
-		SpoilerOf *string
-		Nsfw      bool
+		Ctx          context.Context
+		Content      string
+		SpoilerOf    *string
+		Nsfw         bool
+		Visibility   string
+		QuotedPostID *string
+		Media        []io.Reader
 	}
 	lockServiceMockCreatePost.RLock()
 	calls = mock.calls.CreatePost
@@ -795,6 +1712,173 @@ func (mock *ServiceMock) CreateUserCalls() []struct {
 	return calls
 }
 
+// DeleteAccount calls DeleteAccountFunc.
+func (mock *ServiceMock) DeleteAccount(ctx context.Context) (service.AccountDeletion, error) {
+	if mock.DeleteAccountFunc == nil {
+		panic("ServiceMock.DeleteAccountFunc: method is nil but Service.DeleteAccount was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	lockServiceMockDeleteAccount.Lock()
+	mock.calls.DeleteAccount = append(mock.calls.DeleteAccount, callInfo)
+	lockServiceMockDeleteAccount.Unlock()
+	return mock.DeleteAccountFunc(ctx)
+}
+
+// DeleteAccountCalls gets all the calls that were made to DeleteAccount.
+// Check the length with:
+//     len(mockedService.DeleteAccountCalls())
+func (mock *ServiceMock) DeleteAccountCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	lockServiceMockDeleteAccount.RLock()
+	calls = mock.calls.DeleteAccount
+	lockServiceMockDeleteAccount.RUnlock()
+	return calls
+}
+
+// DeleteAvatar calls DeleteAvatarFunc.
+func (mock *ServiceMock) DeleteAvatar(ctx context.Context) error {
+	if mock.DeleteAvatarFunc == nil {
+		panic("ServiceMock.DeleteAvatarFunc: method is nil but Service.DeleteAvatar was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	lockServiceMockDeleteAvatar.Lock()
+	mock.calls.DeleteAvatar = append(mock.calls.DeleteAvatar, callInfo)
+	lockServiceMockDeleteAvatar.Unlock()
+	return mock.DeleteAvatarFunc(ctx)
+}
+
+// DeleteAvatarCalls gets all the calls that were made to DeleteAvatar.
+// Check the length with:
+//     len(mockedService.DeleteAvatarCalls())
+func (mock *ServiceMock) DeleteAvatarCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	lockServiceMockDeleteAvatar.RLock()
+	calls = mock.calls.DeleteAvatar
+	lockServiceMockDeleteAvatar.RUnlock()
+	return calls
+}
+
+// DeleteComment calls DeleteCommentFunc.
+func (mock *ServiceMock) DeleteComment(ctx context.Context, commentID string) error {
+	if mock.DeleteCommentFunc == nil {
+		panic("ServiceMock.DeleteCommentFunc: method is nil but Service.DeleteComment was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID string
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+	}
+	lockServiceMockDeleteComment.Lock()
+	mock.calls.DeleteComment = append(mock.calls.DeleteComment, callInfo)
+	lockServiceMockDeleteComment.Unlock()
+	return mock.DeleteCommentFunc(ctx, commentID)
+}
+
+// DeleteCommentCalls gets all the calls that were made to DeleteComment.
+// Check the length with:
+//     len(mockedService.DeleteCommentCalls())
+func (mock *ServiceMock) DeleteCommentCalls() []struct {
+	Ctx       context.Context
+	CommentID string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID string
+	}
+	lockServiceMockDeleteComment.RLock()
+	calls = mock.calls.DeleteComment
+	lockServiceMockDeleteComment.RUnlock()
+	return calls
+}
+
+// DeleteMute calls DeleteMuteFunc.
+func (mock *ServiceMock) DeleteMute(ctx context.Context, muteID string) error {
+	if mock.DeleteMuteFunc == nil {
+		panic("ServiceMock.DeleteMuteFunc: method is nil but Service.DeleteMute was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		MuteID string
+	}{
+		Ctx:    ctx,
+		MuteID: muteID,
+	}
+	lockServiceMockDeleteMute.Lock()
+	mock.calls.DeleteMute = append(mock.calls.DeleteMute, callInfo)
+	lockServiceMockDeleteMute.Unlock()
+	return mock.DeleteMuteFunc(ctx, muteID)
+}
+
+// DeleteMuteCalls gets all the calls that were made to DeleteMute.
+// Check the length with:
+//     len(mockedService.DeleteMuteCalls())
+func (mock *ServiceMock) DeleteMuteCalls() []struct {
+	Ctx    context.Context
+	MuteID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		MuteID string
+	}
+	lockServiceMockDeleteMute.RLock()
+	calls = mock.calls.DeleteMute
+	lockServiceMockDeleteMute.RUnlock()
+	return calls
+}
+
+// DeletePost calls DeletePostFunc.
+func (mock *ServiceMock) DeletePost(ctx context.Context, postID string) error {
+	if mock.DeletePostFunc == nil {
+		panic("ServiceMock.DeletePostFunc: method is nil but Service.DeletePost was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		PostID string
+	}{
+		Ctx:    ctx,
+		PostID: postID,
+	}
+	lockServiceMockDeletePost.Lock()
+	mock.calls.DeletePost = append(mock.calls.DeletePost, callInfo)
+	lockServiceMockDeletePost.Unlock()
+	return mock.DeletePostFunc(ctx, postID)
+}
+
+// DeletePostCalls gets all the calls that were made to DeletePost.
+// Check the length with:
+//     len(mockedService.DeletePostCalls())
+func (mock *ServiceMock) DeletePostCalls() []struct {
+	Ctx    context.Context
+	PostID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		PostID string
+	}
+	lockServiceMockDeletePost.RLock()
+	calls = mock.calls.DeletePost
+	lockServiceMockDeletePost.RUnlock()
+	return calls
+}
+
 // DeleteTimelineItem calls DeleteTimelineItemFunc.
 func (mock *ServiceMock) DeleteTimelineItem(ctx context.Context, timelineItemID string) error {
 	if mock.DeleteTimelineItemFunc == nil {
@@ -865,6 +1949,76 @@ func (mock *ServiceMock) DevLoginCalls() []struct {
 	return calls
 }
 
+// Export calls ExportFunc.
+func (mock *ServiceMock) Export(ctx context.Context) (io.ReadCloser, error) {
+	if mock.ExportFunc == nil {
+		panic("ServiceMock.ExportFunc: method is nil but Service.Export was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	lockServiceMockExport.Lock()
+	mock.calls.Export = append(mock.calls.Export, callInfo)
+	lockServiceMockExport.Unlock()
+	return mock.ExportFunc(ctx)
+}
+
+// ExportCalls gets all the calls that were made to Export.
+// Check the length with:
+//     len(mockedService.ExportCalls())
+func (mock *ServiceMock) ExportCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	lockServiceMockExport.RLock()
+	calls = mock.calls.Export
+	lockServiceMockExport.RUnlock()
+	return calls
+}
+
+// FollowRequests calls FollowRequestsFunc.
+func (mock *ServiceMock) FollowRequests(ctx context.Context, first int, after string) ([]service.UserProfile, error) {
+	if mock.FollowRequestsFunc == nil {
+		panic("ServiceMock.FollowRequestsFunc: method is nil but Service.FollowRequests was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		First int
+		After string
+	}{
+		Ctx:   ctx,
+		First: first,
+		After: after,
+	}
+	lockServiceMockFollowRequests.Lock()
+	mock.calls.FollowRequests = append(mock.calls.FollowRequests, callInfo)
+	lockServiceMockFollowRequests.Unlock()
+	return mock.FollowRequestsFunc(ctx, first, after)
+}
+
+// FollowRequestsCalls gets all the calls that were made to FollowRequests.
+// Check the length with:
+//     len(mockedService.FollowRequestsCalls())
+func (mock *ServiceMock) FollowRequestsCalls() []struct {
+	Ctx   context.Context
+	First int
+	After string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		First int
+		After string
+	}
+	lockServiceMockFollowRequests.RLock()
+	calls = mock.calls.FollowRequests
+	lockServiceMockFollowRequests.RUnlock()
+	return calls
+}
+
 // Followees calls FolloweesFunc.
 func (mock *ServiceMock) Followees(ctx context.Context, username string, first int, after string) ([]service.UserProfile, error) {
 	if mock.FolloweesFunc == nil {
@@ -951,6 +2105,37 @@ func (mock *ServiceMock) FollowersCalls() []struct {
 	return calls
 }
 
+// HasUnreadMessages calls HasUnreadMessagesFunc.
+func (mock *ServiceMock) HasUnreadMessages(ctx context.Context) (bool, error) {
+	if mock.HasUnreadMessagesFunc == nil {
+		panic("ServiceMock.HasUnreadMessagesFunc: method is nil but Service.HasUnreadMessages was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	lockServiceMockHasUnreadMessages.Lock()
+	mock.calls.HasUnreadMessages = append(mock.calls.HasUnreadMessages, callInfo)
+	lockServiceMockHasUnreadMessages.Unlock()
+	return mock.HasUnreadMessagesFunc(ctx)
+}
+
+// HasUnreadMessagesCalls gets all the calls that were made to HasUnreadMessages.
+// Check the length with:
+//     len(mockedService.HasUnreadMessagesCalls())
+func (mock *ServiceMock) HasUnreadMessagesCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	lockServiceMockHasUnreadMessages.RLock()
+	calls = mock.calls.HasUnreadMessages
+	lockServiceMockHasUnreadMessages.RUnlock()
+	return calls
+}
+
 // HasUnreadNotifications calls HasUnreadNotificationsFunc.
 func (mock *ServiceMock) HasUnreadNotifications(ctx context.Context) (bool, error) {
 	if mock.HasUnreadNotificationsFunc == nil {
@@ -982,6 +2167,76 @@ func (mock *ServiceMock) HasUnreadNotificationsCalls() []struct {
 	return calls
 }
 
+// Identicon calls IdenticonFunc.
+func (mock *ServiceMock) Identicon(username string, size int) ([]byte, error) {
+	if mock.IdenticonFunc == nil {
+		panic("ServiceMock.IdenticonFunc: method is nil but Service.Identicon was just called")
+	}
+	callInfo := struct {
+		Username string
+		Size     int
+	}{
+		Username: username,
+		Size:     size,
+	}
+	lockServiceMockIdenticon.Lock()
+	mock.calls.Identicon = append(mock.calls.Identicon, callInfo)
+	lockServiceMockIdenticon.Unlock()
+	return mock.IdenticonFunc(username, size)
+}
+
+// IdenticonCalls gets all the calls that were made to Identicon.
+// Check the length with:
+//     len(mockedService.IdenticonCalls())
+func (mock *ServiceMock) IdenticonCalls() []struct {
+	Username string
+	Size     int
+} {
+	var calls []struct {
+		Username string
+		Size     int
+	}
+	lockServiceMockIdenticon.RLock()
+	calls = mock.calls.Identicon
+	lockServiceMockIdenticon.RUnlock()
+	return calls
+}
+
+// MarkConversationAsRead calls MarkConversationAsReadFunc.
+func (mock *ServiceMock) MarkConversationAsRead(ctx context.Context, conversationID string) error {
+	if mock.MarkConversationAsReadFunc == nil {
+		panic("ServiceMock.MarkConversationAsReadFunc: method is nil but Service.MarkConversationAsRead was just called")
+	}
+	callInfo := struct {
+		Ctx            context.Context
+		ConversationID string
+	}{
+		Ctx:            ctx,
+		ConversationID: conversationID,
+	}
+	lockServiceMockMarkConversationAsRead.Lock()
+	mock.calls.MarkConversationAsRead = append(mock.calls.MarkConversationAsRead, callInfo)
+	lockServiceMockMarkConversationAsRead.Unlock()
+	return mock.MarkConversationAsReadFunc(ctx, conversationID)
+}
+
+// MarkConversationAsReadCalls gets all the calls that were made to MarkConversationAsRead.
+// Check the length with:
+//     len(mockedService.MarkConversationAsReadCalls())
+func (mock *ServiceMock) MarkConversationAsReadCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		ConversationID string
+	}
+	lockServiceMockMarkConversationAsRead.RLock()
+	calls = mock.calls.MarkConversationAsRead
+	lockServiceMockMarkConversationAsRead.RUnlock()
+	return calls
+}
+
 // MarkNotificationAsRead calls MarkNotificationAsReadFunc.
 func (mock *ServiceMock) MarkNotificationAsRead(ctx context.Context, notificationID string) error {
 	if mock.MarkNotificationAsReadFunc == nil {
@@ -1048,6 +2303,189 @@ func (mock *ServiceMock) MarkNotificationsAsReadCalls() []struct {
 	return calls
 }
 
+// MessageStream calls MessageStreamFunc.
+func (mock *ServiceMock) MessageStream(ctx context.Context) (<-chan service.Message, error) {
+	if mock.MessageStreamFunc == nil {
+		panic("ServiceMock.MessageStreamFunc: method is nil but Service.MessageStream was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	lockServiceMockMessageStream.Lock()
+	mock.calls.MessageStream = append(mock.calls.MessageStream, callInfo)
+	lockServiceMockMessageStream.Unlock()
+	return mock.MessageStreamFunc(ctx)
+}
+
+// MessageStreamCalls gets all the calls that were made to MessageStream.
+// Check the length with:
+//     len(mockedService.MessageStreamCalls())
+func (mock *ServiceMock) MessageStreamCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	lockServiceMockMessageStream.RLock()
+	calls = mock.calls.MessageStream
+	lockServiceMockMessageStream.RUnlock()
+	return calls
+}
+
+// Messages calls MessagesFunc.
+func (mock *ServiceMock) Messages(ctx context.Context, conversationID string, last int, before string) (service.MessagesOutput, error) {
+	if mock.MessagesFunc == nil {
+		panic("ServiceMock.MessagesFunc: method is nil but Service.Messages was just called")
+	}
+	callInfo := struct {
+		Ctx            context.Context
+		ConversationID string
+		Last           int
+		Before         string
+	}{
+		Ctx:            ctx,
+		ConversationID: conversationID,
+		Last:           last,
+		Before:         before,
+	}
+	lockServiceMockMessages.Lock()
+	mock.calls.Messages = append(mock.calls.Messages, callInfo)
+	lockServiceMockMessages.Unlock()
+	return mock.MessagesFunc(ctx, conversationID, last, before)
+}
+
+// MessagesCalls gets all the calls that were made to Messages.
+// Check the length with:
+//     len(mockedService.MessagesCalls())
+func (mock *ServiceMock) MessagesCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
+	Last           int
+	Before         string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		ConversationID string
+		Last           int
+		Before         string
+	}
+	lockServiceMockMessages.RLock()
+	calls = mock.calls.Messages
+	lockServiceMockMessages.RUnlock()
+	return calls
+}
+
+// MuteUser calls MuteUserFunc.
+func (mock *ServiceMock) MuteUser(ctx context.Context, username string, expiresAt *time.Time) (service.Mute, error) {
+	if mock.MuteUserFunc == nil {
+		panic("ServiceMock.MuteUserFunc: method is nil but Service.MuteUser was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Username  string
+		ExpiresAt *time.Time
+	}{
+		Ctx:       ctx,
+		Username:  username,
+		ExpiresAt: expiresAt,
+	}
+	lockServiceMockMuteUser.Lock()
+	mock.calls.MuteUser = append(mock.calls.MuteUser, callInfo)
+	lockServiceMockMuteUser.Unlock()
+	return mock.MuteUserFunc(ctx, username, expiresAt)
+}
+
+// MuteUserCalls gets all the calls that were made to MuteUser.
+// Check the length with:
+//     len(mockedService.MuteUserCalls())
+func (mock *ServiceMock) MuteUserCalls() []struct {
+	Ctx       context.Context
+	Username  string
+	ExpiresAt *time.Time
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Username  string
+		ExpiresAt *time.Time
+	}
+	lockServiceMockMuteUser.RLock()
+	calls = mock.calls.MuteUser
+	lockServiceMockMuteUser.RUnlock()
+	return calls
+}
+
+// MuteWord calls MuteWordFunc.
+func (mock *ServiceMock) MuteWord(ctx context.Context, word string, expiresAt *time.Time) (service.Mute, error) {
+	if mock.MuteWordFunc == nil {
+		panic("ServiceMock.MuteWordFunc: method is nil but Service.MuteWord was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		Word      string
+		ExpiresAt *time.Time
+	}{
+		Ctx:       ctx,
+		Word:      word,
+		ExpiresAt: expiresAt,
+	}
+	lockServiceMockMuteWord.Lock()
+	mock.calls.MuteWord = append(mock.calls.MuteWord, callInfo)
+	lockServiceMockMuteWord.Unlock()
+	return mock.MuteWordFunc(ctx, word, expiresAt)
+}
+
+// MuteWordCalls gets all the calls that were made to MuteWord.
+// Check the length with:
+//     len(mockedService.MuteWordCalls())
+func (mock *ServiceMock) MuteWordCalls() []struct {
+	Ctx       context.Context
+	Word      string
+	ExpiresAt *time.Time
+} {
+	var calls []struct {
+		Ctx       context.Context
+		Word      string
+		ExpiresAt *time.Time
+	}
+	lockServiceMockMuteWord.RLock()
+	calls = mock.calls.MuteWord
+	lockServiceMockMuteWord.RUnlock()
+	return calls
+}
+
+// Mutes calls MutesFunc.
+func (mock *ServiceMock) Mutes(ctx context.Context) ([]service.Mute, error) {
+	if mock.MutesFunc == nil {
+		panic("ServiceMock.MutesFunc: method is nil but Service.Mutes was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	lockServiceMockMutes.Lock()
+	mock.calls.Mutes = append(mock.calls.Mutes, callInfo)
+	lockServiceMockMutes.Unlock()
+	return mock.MutesFunc(ctx)
+}
+
+// MutesCalls gets all the calls that were made to Mutes.
+// Check the length with:
+//     len(mockedService.MutesCalls())
+func (mock *ServiceMock) MutesCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	lockServiceMockMutes.RLock()
+	calls = mock.calls.Mutes
+	lockServiceMockMutes.RUnlock()
+	return calls
+}
+
 // NotificationStream calls NotificationStreamFunc.
 func (mock *ServiceMock) NotificationStream(ctx context.Context) (<-chan service.Notification, error) {
 	if mock.NotificationStreamFunc == nil {
@@ -1080,7 +2518,7 @@ func (mock *ServiceMock) NotificationStreamCalls() []struct {
 }
 
 // Notifications calls NotificationsFunc.
-func (mock *ServiceMock) Notifications(ctx context.Context, last int, before string) ([]service.Notification, error) {
+func (mock *ServiceMock) Notifications(ctx context.Context, last int, before string) (service.NotificationsOutput, error) {
 	if mock.NotificationsFunc == nil {
 		panic("ServiceMock.NotificationsFunc: method is nil but Service.Notifications was just called")
 	}
@@ -1153,8 +2591,43 @@ func (mock *ServiceMock) PostCalls() []struct {
 	return calls
 }
 
+// PostRevisions calls PostRevisionsFunc.
+func (mock *ServiceMock) PostRevisions(ctx context.Context, postID string) ([]service.PostRevision, error) {
+	if mock.PostRevisionsFunc == nil {
+		panic("ServiceMock.PostRevisionsFunc: method is nil but Service.PostRevisions was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		PostID string
+	}{
+		Ctx:    ctx,
+		PostID: postID,
+	}
+	lockServiceMockPostRevisions.Lock()
+	mock.calls.PostRevisions = append(mock.calls.PostRevisions, callInfo)
+	lockServiceMockPostRevisions.Unlock()
+	return mock.PostRevisionsFunc(ctx, postID)
+}
+
+// PostRevisionsCalls gets all the calls that were made to PostRevisions.
+// Check the length with:
+//     len(mockedService.PostRevisionsCalls())
+func (mock *ServiceMock) PostRevisionsCalls() []struct {
+	Ctx    context.Context
+	PostID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		PostID string
+	}
+	lockServiceMockPostRevisions.RLock()
+	calls = mock.calls.PostRevisions
+	lockServiceMockPostRevisions.RUnlock()
+	return calls
+}
+
 // Posts calls PostsFunc.
-func (mock *ServiceMock) Posts(ctx context.Context, username string, last int, before string) ([]service.Post, error) {
+func (mock *ServiceMock) Posts(ctx context.Context, username string, last int, before string) (service.PostsOutput, error) {
 	if mock.PostsFunc == nil {
 		panic("ServiceMock.PostsFunc: method is nil but Service.Posts was just called")
 	}
@@ -1175,24 +2648,211 @@ func (mock *ServiceMock) Posts(ctx context.Context, username string, last int, b
 	return mock.PostsFunc(ctx, username, last, before)
 }
 
-// PostsCalls gets all the calls that were made to Posts.
+// PostsCalls gets all the calls that were made to Posts.
+// Check the length with:
+//     len(mockedService.PostsCalls())
+func (mock *ServiceMock) PostsCalls() []struct {
+	Ctx      context.Context
+	Username string
+	Last     int
+	Before   string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Username string
+		Last     int
+		Before   string
+	}
+	lockServiceMockPosts.RLock()
+	calls = mock.calls.Posts
+	lockServiceMockPosts.RUnlock()
+	return calls
+}
+
+// RankedTimeline calls RankedTimelineFunc.
+func (mock *ServiceMock) RankedTimeline(ctx context.Context, first int, after string) (service.RankedTimelineOutput, error) {
+	if mock.RankedTimelineFunc == nil {
+		panic("ServiceMock.RankedTimelineFunc: method is nil but Service.RankedTimeline was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		First int
+		After string
+	}{
+		Ctx:   ctx,
+		First: first,
+		After: after,
+	}
+	lockServiceMockRankedTimeline.Lock()
+	mock.calls.RankedTimeline = append(mock.calls.RankedTimeline, callInfo)
+	lockServiceMockRankedTimeline.Unlock()
+	return mock.RankedTimelineFunc(ctx, first, after)
+}
+
+// RankedTimelineCalls gets all the calls that were made to RankedTimeline.
+// Check the length with:
+//     len(mockedService.RankedTimelineCalls())
+func (mock *ServiceMock) RankedTimelineCalls() []struct {
+	Ctx   context.Context
+	First int
+	After string
+} {
+	var calls []struct {
+		Ctx   context.Context
+		First int
+		After string
+	}
+	lockServiceMockRankedTimeline.RLock()
+	calls = mock.calls.RankedTimeline
+	lockServiceMockRankedTimeline.RUnlock()
+	return calls
+}
+
+// RejectFollowRequest calls RejectFollowRequestFunc.
+func (mock *ServiceMock) RejectFollowRequest(ctx context.Context, username string) error {
+	if mock.RejectFollowRequestFunc == nil {
+		panic("ServiceMock.RejectFollowRequestFunc: method is nil but Service.RejectFollowRequest was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Username string
+	}{
+		Ctx:      ctx,
+		Username: username,
+	}
+	lockServiceMockRejectFollowRequest.Lock()
+	mock.calls.RejectFollowRequest = append(mock.calls.RejectFollowRequest, callInfo)
+	lockServiceMockRejectFollowRequest.Unlock()
+	return mock.RejectFollowRequestFunc(ctx, username)
+}
+
+// RejectFollowRequestCalls gets all the calls that were made to RejectFollowRequest.
+// Check the length with:
+//     len(mockedService.RejectFollowRequestCalls())
+func (mock *ServiceMock) RejectFollowRequestCalls() []struct {
+	Ctx      context.Context
+	Username string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Username string
+	}
+	lockServiceMockRejectFollowRequest.RLock()
+	calls = mock.calls.RejectFollowRequest
+	lockServiceMockRejectFollowRequest.RUnlock()
+	return calls
+}
+
+// RenamedUsername calls RenamedUsernameFunc.
+func (mock *ServiceMock) RenamedUsername(ctx context.Context, oldUsername string) (string, error) {
+	if mock.RenamedUsernameFunc == nil {
+		panic("ServiceMock.RenamedUsernameFunc: method is nil but Service.RenamedUsername was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		OldUsername string
+	}{
+		Ctx:         ctx,
+		OldUsername: oldUsername,
+	}
+	lockServiceMockRenamedUsername.Lock()
+	mock.calls.RenamedUsername = append(mock.calls.RenamedUsername, callInfo)
+	lockServiceMockRenamedUsername.Unlock()
+	return mock.RenamedUsernameFunc(ctx, oldUsername)
+}
+
+// RenamedUsernameCalls gets all the calls that were made to RenamedUsername.
+// Check the length with:
+//     len(mockedService.RenamedUsernameCalls())
+func (mock *ServiceMock) RenamedUsernameCalls() []struct {
+	Ctx         context.Context
+	OldUsername string
+} {
+	var calls []struct {
+		Ctx         context.Context
+		OldUsername string
+	}
+	lockServiceMockRenamedUsername.RLock()
+	calls = mock.calls.RenamedUsername
+	lockServiceMockRenamedUsername.RUnlock()
+	return calls
+}
+
+// Repost calls RepostFunc.
+func (mock *ServiceMock) Repost(ctx context.Context, postID string) (service.RepostOutput, error) {
+	if mock.RepostFunc == nil {
+		panic("ServiceMock.RepostFunc: method is nil but Service.Repost was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		PostID string
+	}{
+		Ctx:    ctx,
+		PostID: postID,
+	}
+	lockServiceMockRepost.Lock()
+	mock.calls.Repost = append(mock.calls.Repost, callInfo)
+	lockServiceMockRepost.Unlock()
+	return mock.RepostFunc(ctx, postID)
+}
+
+// RepostCalls gets all the calls that were made to Repost.
+// Check the length with:
+//     len(mockedService.RepostCalls())
+func (mock *ServiceMock) RepostCalls() []struct {
+	Ctx    context.Context
+	PostID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		PostID string
+	}
+	lockServiceMockRepost.RLock()
+	calls = mock.calls.Repost
+	lockServiceMockRepost.RUnlock()
+	return calls
+}
+
+// SearchPosts calls SearchPostsFunc.
+func (mock *ServiceMock) SearchPosts(ctx context.Context, q string, first int, after string) (service.SearchPostsOutput, error) {
+	if mock.SearchPostsFunc == nil {
+		panic("ServiceMock.SearchPostsFunc: method is nil but Service.SearchPosts was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Q     string
+		First int
+		After string
+	}{
+		Ctx:   ctx,
+		Q:     q,
+		First: first,
+		After: after,
+	}
+	lockServiceMockSearchPosts.Lock()
+	mock.calls.SearchPosts = append(mock.calls.SearchPosts, callInfo)
+	lockServiceMockSearchPosts.Unlock()
+	return mock.SearchPostsFunc(ctx, q, first, after)
+}
+
+// SearchPostsCalls gets all the calls that were made to SearchPosts.
 // Check the length with:
-//     len(mockedService.PostsCalls())
-func (mock *ServiceMock) PostsCalls() []struct {
-	Ctx      context.Context
-	Username string
-	Last     int
-	Before   string
+//     len(mockedService.SearchPostsCalls())
+func (mock *ServiceMock) SearchPostsCalls() []struct {
+	Ctx   context.Context
+	Q     string
+	First int
+	After string
 } {
 	var calls []struct {
-		Ctx      context.Context
-		Username string
-		Last     int
-		Before   string
+		Ctx   context.Context
+		Q     string
+		First int
+		After string
 	}
-	lockServiceMockPosts.RLock()
-	calls = mock.calls.Posts
-	lockServiceMockPosts.RUnlock()
+	lockServiceMockSearchPosts.RLock()
+	calls = mock.calls.SearchPosts
+	lockServiceMockSearchPosts.RUnlock()
 	return calls
 }
 
@@ -1235,8 +2895,90 @@ func (mock *ServiceMock) SendMagicLinkCalls() []struct {
 	return calls
 }
 
+// SendMessage calls SendMessageFunc.
+func (mock *ServiceMock) SendMessage(ctx context.Context, conversationID string, content string) (service.Message, error) {
+	if mock.SendMessageFunc == nil {
+		panic("ServiceMock.SendMessageFunc: method is nil but Service.SendMessage was just called")
+	}
+	callInfo := struct {
+		Ctx            context.Context
+		ConversationID string
+		Content        string
+	}{
+		Ctx:            ctx,
+		ConversationID: conversationID,
+		Content:        content,
+	}
+	lockServiceMockSendMessage.Lock()
+	mock.calls.SendMessage = append(mock.calls.SendMessage, callInfo)
+	lockServiceMockSendMessage.Unlock()
+	return mock.SendMessageFunc(ctx, conversationID, content)
+}
+
+// SendMessageCalls gets all the calls that were made to SendMessage.
+// Check the length with:
+//     len(mockedService.SendMessageCalls())
+func (mock *ServiceMock) SendMessageCalls() []struct {
+	Ctx            context.Context
+	ConversationID string
+	Content        string
+} {
+	var calls []struct {
+		Ctx            context.Context
+		ConversationID string
+		Content        string
+	}
+	lockServiceMockSendMessage.RLock()
+	calls = mock.calls.SendMessage
+	lockServiceMockSendMessage.RUnlock()
+	return calls
+}
+
+// TagPosts calls TagPostsFunc.
+func (mock *ServiceMock) TagPosts(ctx context.Context, tag string, last int, before string) (service.PostsOutput, error) {
+	if mock.TagPostsFunc == nil {
+		panic("ServiceMock.TagPostsFunc: method is nil but Service.TagPosts was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Tag    string
+		Last   int
+		Before string
+	}{
+		Ctx:    ctx,
+		Tag:    tag,
+		Last:   last,
+		Before: before,
+	}
+	lockServiceMockTagPosts.Lock()
+	mock.calls.TagPosts = append(mock.calls.TagPosts, callInfo)
+	lockServiceMockTagPosts.Unlock()
+	return mock.TagPostsFunc(ctx, tag, last, before)
+}
+
+// TagPostsCalls gets all the calls that were made to TagPosts.
+// Check the length with:
+//     len(mockedService.TagPostsCalls())
+func (mock *ServiceMock) TagPostsCalls() []struct {
+	Ctx    context.Context
+	Tag    string
+	Last   int
+	Before string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Tag    string
+		Last   int
+		Before string
+	}
+	lockServiceMockTagPosts.RLock()
+	calls = mock.calls.TagPosts
+	lockServiceMockTagPosts.RUnlock()
+	return calls
+}
+
 // Timeline calls TimelineFunc.
-func (mock *ServiceMock) Timeline(ctx context.Context, last int, before string) ([]service.TimelineItem, error) {
+func (mock *ServiceMock) Timeline(ctx context.Context, last int, before string) (service.TimelineOutput, error) {
 	if mock.TimelineFunc == nil {
 		panic("ServiceMock.TimelineFunc: method is nil but Service.Timeline was just called")
 	}
@@ -1305,6 +3047,41 @@ func (mock *ServiceMock) TimelineItemStreamCalls() []struct {
 	return calls
 }
 
+// ToggleBlock calls ToggleBlockFunc.
+func (mock *ServiceMock) ToggleBlock(ctx context.Context, username string) (service.ToggleBlockOutput, error) {
+	if mock.ToggleBlockFunc == nil {
+		panic("ServiceMock.ToggleBlockFunc: method is nil but Service.ToggleBlock was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Username string
+	}{
+		Ctx:      ctx,
+		Username: username,
+	}
+	lockServiceMockToggleBlock.Lock()
+	mock.calls.ToggleBlock = append(mock.calls.ToggleBlock, callInfo)
+	lockServiceMockToggleBlock.Unlock()
+	return mock.ToggleBlockFunc(ctx, username)
+}
+
+// ToggleBlockCalls gets all the calls that were made to ToggleBlock.
+// Check the length with:
+//     len(mockedService.ToggleBlockCalls())
+func (mock *ServiceMock) ToggleBlockCalls() []struct {
+	Ctx      context.Context
+	Username string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Username string
+	}
+	lockServiceMockToggleBlock.RLock()
+	calls = mock.calls.ToggleBlock
+	lockServiceMockToggleBlock.RUnlock()
+	return calls
+}
+
 // ToggleCommentLike calls ToggleCommentLikeFunc.
 func (mock *ServiceMock) ToggleCommentLike(ctx context.Context, commentID string) (service.ToggleLikeOutput, error) {
 	if mock.ToggleCommentLikeFunc == nil {
@@ -1476,6 +3253,80 @@ func (mock *ServiceMock) TokenCalls() []struct {
 	return calls
 }
 
+// TrendingTags calls TrendingTagsFunc.
+func (mock *ServiceMock) TrendingTags(ctx context.Context, window time.Duration, first int) ([]service.TrendingTag, error) {
+	if mock.TrendingTagsFunc == nil {
+		panic("ServiceMock.TrendingTagsFunc: method is nil but Service.TrendingTags was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Window time.Duration
+		First  int
+	}{
+		Ctx:    ctx,
+		Window: window,
+		First:  first,
+	}
+	lockServiceMockTrendingTags.Lock()
+	mock.calls.TrendingTags = append(mock.calls.TrendingTags, callInfo)
+	lockServiceMockTrendingTags.Unlock()
+	return mock.TrendingTagsFunc(ctx, window, first)
+}
+
+// TrendingTagsCalls gets all the calls that were made to TrendingTags.
+// Check the length with:
+//     len(mockedService.TrendingTagsCalls())
+func (mock *ServiceMock) TrendingTagsCalls() []struct {
+	Ctx    context.Context
+	Window time.Duration
+	First  int
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Window time.Duration
+		First  int
+	}
+	lockServiceMockTrendingTags.RLock()
+	calls = mock.calls.TrendingTags
+	lockServiceMockTrendingTags.RUnlock()
+	return calls
+}
+
+// Unrepost calls UnrepostFunc.
+func (mock *ServiceMock) Unrepost(ctx context.Context, postID string) (service.RepostOutput, error) {
+	if mock.UnrepostFunc == nil {
+		panic("ServiceMock.UnrepostFunc: method is nil but Service.Unrepost was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		PostID string
+	}{
+		Ctx:    ctx,
+		PostID: postID,
+	}
+	lockServiceMockUnrepost.Lock()
+	mock.calls.Unrepost = append(mock.calls.Unrepost, callInfo)
+	lockServiceMockUnrepost.Unlock()
+	return mock.UnrepostFunc(ctx, postID)
+}
+
+// UnrepostCalls gets all the calls that were made to Unrepost.
+// Check the length with:
+//     len(mockedService.UnrepostCalls())
+func (mock *ServiceMock) UnrepostCalls() []struct {
+	Ctx    context.Context
+	PostID string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		PostID string
+	}
+	lockServiceMockUnrepost.RLock()
+	calls = mock.calls.Unrepost
+	lockServiceMockUnrepost.RUnlock()
+	return calls
+}
+
 // UpdateAvatar calls UpdateAvatarFunc.
 func (mock *ServiceMock) UpdateAvatar(ctx context.Context, r io.Reader) (string, error) {
 	if mock.UpdateAvatarFunc == nil {
@@ -1511,6 +3362,221 @@ func (mock *ServiceMock) UpdateAvatarCalls() []struct {
 	return calls
 }
 
+// UpdateComment calls UpdateCommentFunc.
+func (mock *ServiceMock) UpdateComment(ctx context.Context, commentID string, content string) (service.Comment, error) {
+	if mock.UpdateCommentFunc == nil {
+		panic("ServiceMock.UpdateCommentFunc: method is nil but Service.UpdateComment was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		CommentID string
+		Content   string
+	}{
+		Ctx:       ctx,
+		CommentID: commentID,
+		Content:   content,
+	}
+	lockServiceMockUpdateComment.Lock()
+	mock.calls.UpdateComment = append(mock.calls.UpdateComment, callInfo)
+	lockServiceMockUpdateComment.Unlock()
+	return mock.UpdateCommentFunc(ctx, commentID, content)
+}
+
+// UpdateCommentCalls gets all the calls that were made to UpdateComment.
+// Check the length with:
+//     len(mockedService.UpdateCommentCalls())
+func (mock *ServiceMock) UpdateCommentCalls() []struct {
+	Ctx       context.Context
+	CommentID string
+	Content   string
+} {
+	var calls []struct {
+		Ctx       context.Context
+		CommentID string
+		Content   string
+	}
+	lockServiceMockUpdateComment.RLock()
+	calls = mock.calls.UpdateComment
+	lockServiceMockUpdateComment.RUnlock()
+	return calls
+}
+
+// UpdateEmail calls UpdateEmailFunc.
+func (mock *ServiceMock) UpdateEmail(ctx context.Context, email string, redirectURI string) error {
+	if mock.UpdateEmailFunc == nil {
+		panic("ServiceMock.UpdateEmailFunc: method is nil but Service.UpdateEmail was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		Email       string
+		RedirectURI string
+	}{
+		Ctx:         ctx,
+		Email:       email,
+		RedirectURI: redirectURI,
+	}
+	lockServiceMockUpdateEmail.Lock()
+	mock.calls.UpdateEmail = append(mock.calls.UpdateEmail, callInfo)
+	lockServiceMockUpdateEmail.Unlock()
+	return mock.UpdateEmailFunc(ctx, email, redirectURI)
+}
+
+// UpdateEmailCalls gets all the calls that were made to UpdateEmail.
+// Check the length with:
+//     len(mockedService.UpdateEmailCalls())
+func (mock *ServiceMock) UpdateEmailCalls() []struct {
+	Ctx         context.Context
+	Email       string
+	RedirectURI string
+} {
+	var calls []struct {
+		Ctx         context.Context
+		Email       string
+		RedirectURI string
+	}
+	lockServiceMockUpdateEmail.RLock()
+	calls = mock.calls.UpdateEmail
+	lockServiceMockUpdateEmail.RUnlock()
+	return calls
+}
+
+// UpdateMute calls UpdateMuteFunc.
+func (mock *ServiceMock) UpdateMute(ctx context.Context, muteID string, expiresAt *time.Time) (service.Mute, error) {
+	if mock.UpdateMuteFunc == nil {
+		panic("ServiceMock.UpdateMuteFunc: method is nil but Service.UpdateMute was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		MuteID    string
+		ExpiresAt *time.Time
+	}{
+		Ctx:       ctx,
+		MuteID:    muteID,
+		ExpiresAt: expiresAt,
+	}
+	lockServiceMockUpdateMute.Lock()
+	mock.calls.UpdateMute = append(mock.calls.UpdateMute, callInfo)
+	lockServiceMockUpdateMute.Unlock()
+	return mock.UpdateMuteFunc(ctx, muteID, expiresAt)
+}
+
+// UpdateMuteCalls gets all the calls that were made to UpdateMute.
+// Check the length with:
+//     len(mockedService.UpdateMuteCalls())
+func (mock *ServiceMock) UpdateMuteCalls() []struct {
+	Ctx       context.Context
+	MuteID    string
+	ExpiresAt *time.Time
+} {
+	var calls []struct {
+		Ctx       context.Context
+		MuteID    string
+		ExpiresAt *time.Time
+	}
+	lockServiceMockUpdateMute.RLock()
+	calls = mock.calls.UpdateMute
+	lockServiceMockUpdateMute.RUnlock()
+	return calls
+}
+
+// UpdatePost calls UpdatePostFunc.
+func (mock *ServiceMock) UpdatePost(ctx context.Context, postID string, content *string, spoilerOf *string, nsfw *bool) (service.Post, error) {
+	if mock.UpdatePostFunc == nil {
+		panic("ServiceMock.UpdatePostFunc: method is nil but Service.UpdatePost was just called")
+	}
+	callInfo := struct {
+		Ctx       context.Context
+		PostID    string
+		Content   *string
+		SpoilerOf *string
+		Nsfw      *bool
+	}{
+		Ctx:       ctx,
+		PostID:    postID,
+		Content:   content,
+		SpoilerOf: spoilerOf,
+		Nsfw:      nsfw,
+	}
+	lockServiceMockUpdatePost.Lock()
+	mock.calls.UpdatePost = append(mock.calls.UpdatePost, callInfo)
+	lockServiceMockUpdatePost.Unlock()
+	return mock.UpdatePostFunc(ctx, postID, content, spoilerOf, nsfw)
+}
+
+// UpdatePostCalls gets all the calls that were made to UpdatePost.
+// Check the length with:
+//     len(mockedService.UpdatePostCalls())
+func (mock *ServiceMock) UpdatePostCalls() []struct {
+	Ctx       context.Context
+	PostID    string
+	Content   *string
+	SpoilerOf *string
+	Nsfw      *bool
+} {
+	var calls []struct {
+		Ctx       context.Context
+		PostID    string
+		Content   *string
+		SpoilerOf *string
+		Nsfw      *bool
+	}
+	lockServiceMockUpdatePost.RLock()
+	calls = mock.calls.UpdatePost
+	lockServiceMockUpdatePost.RUnlock()
+	return calls
+}
+
+// UpdateUser calls UpdateUserFunc.
+func (mock *ServiceMock) UpdateUser(ctx context.Context, displayName *string, bio *string, location *string, website *string, private *bool) (service.UserProfile, error) {
+	if mock.UpdateUserFunc == nil {
+		panic("ServiceMock.UpdateUserFunc: method is nil but Service.UpdateUser was just called")
+	}
+	callInfo := struct {
+		Ctx         context.Context
+		DisplayName *string
+		Bio         *string
+		Location    *string
+		Website     *string
+		Private     *bool
+	}{
+		Ctx:         ctx,
+		DisplayName: displayName,
+		Bio:         bio,
+		Location:    location,
+		Website:     website,
+		Private:     private,
+	}
+	lockServiceMockUpdateUser.Lock()
+	mock.calls.UpdateUser = append(mock.calls.UpdateUser, callInfo)
+	lockServiceMockUpdateUser.Unlock()
+	return mock.UpdateUserFunc(ctx, displayName, bio, location, website, private)
+}
+
+// UpdateUserCalls gets all the calls that were made to UpdateUser.
+// Check the length with:
+//     len(mockedService.UpdateUserCalls())
+func (mock *ServiceMock) UpdateUserCalls() []struct {
+	Ctx         context.Context
+	DisplayName *string
+	Bio         *string
+	Location    *string
+	Website     *string
+	Private     *bool
+} {
+	var calls []struct {
+		Ctx         context.Context
+		DisplayName *string
+		Bio         *string
+		Location    *string
+		Website     *string
+		Private     *bool
+	}
+	lockServiceMockUpdateUser.RLock()
+	calls = mock.calls.UpdateUser
+	lockServiceMockUpdateUser.RUnlock()
+	return calls
+}
+
 // User calls UserFunc.
 func (mock *ServiceMock) User(ctx context.Context, username string) (service.UserProfile, error) {
 	if mock.UserFunc == nil {
@@ -1547,7 +3613,7 @@ func (mock *ServiceMock) UserCalls() []struct {
 }
 
 // Usernames calls UsernamesFunc.
-func (mock *ServiceMock) Usernames(ctx context.Context, startingWith string, first int, after string) ([]string, error) {
+func (mock *ServiceMock) Usernames(ctx context.Context, startingWith string, first int, after string) (service.UsernamesOutput, error) {
 	if mock.UsernamesFunc == nil {
 		panic("ServiceMock.UsernamesFunc: method is nil but Service.Usernames was just called")
 	}
@@ -1590,7 +3656,7 @@ func (mock *ServiceMock) UsernamesCalls() []struct {
 }
 
 // Users calls UsersFunc.
-func (mock *ServiceMock) Users(ctx context.Context, search string, first int, after string) ([]service.UserProfile, error) {
+func (mock *ServiceMock) Users(ctx context.Context, search string, first int, after string) (service.UsersOutput, error) {
 	if mock.UsersFunc == nil {
 		panic("ServiceMock.UsersFunc: method is nil but Service.Users was just called")
 	}
@@ -1631,3 +3697,42 @@ func (mock *ServiceMock) UsersCalls() []struct {
 	lockServiceMockUsers.RUnlock()
 	return calls
 }
+
+// VerifyEmail calls VerifyEmailFunc.
+func (mock *ServiceMock) VerifyEmail(ctx context.Context, verificationCode string, redirectURI string) (string, error) {
+	if mock.VerifyEmailFunc == nil {
+		panic("ServiceMock.VerifyEmailFunc: method is nil but Service.VerifyEmail was just called")
+	}
+	callInfo := struct {
+		Ctx              context.Context
+		VerificationCode string
+		RedirectURI      string
+	}{
+		Ctx:              ctx,
+		VerificationCode: verificationCode,
+		RedirectURI:      redirectURI,
+	}
+	lockServiceMockVerifyEmail.Lock()
+	mock.calls.VerifyEmail = append(mock.calls.VerifyEmail, callInfo)
+	lockServiceMockVerifyEmail.Unlock()
+	return mock.VerifyEmailFunc(ctx, verificationCode, redirectURI)
+}
+
+// VerifyEmailCalls gets all the calls that were made to VerifyEmail.
+// Check the length with:
+//     len(mockedService.VerifyEmailCalls())
+func (mock *ServiceMock) VerifyEmailCalls() []struct {
+	Ctx              context.Context
+	VerificationCode string
+	RedirectURI      string
+} {
+	var calls []struct {
+		Ctx              context.Context
+		VerificationCode string
+		RedirectURI      string
+	}
+	lockServiceMockVerifyEmail.RLock()
+	calls = mock.calls.VerifyEmail
+	lockServiceMockVerifyEmail.RUnlock()
+	return calls
+}
@@ -0,0 +1,59 @@
+package handler
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/matryer/way"
+	"github.com/nicolasparada/nakama/internal/service"
+)
+
+// 获取使用了某个话题标签的帖子
+func (h *handler) tagPosts(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	tag := way.Param(ctx, "tag")
+	last, _ := strconv.Atoi(q.Get("last"))
+	before := q.Get("before")
+	pp, err := h.TagPosts(ctx, tag, last, before)
+	if err == service.ErrInvalidTag || err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, pp, http.StatusOK)
+}
+
+// 获取热门话题标签，window 是统计的时间范围，比如 1h 或者 24h
+func (h *handler) trendingTags(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	var window time.Duration
+	if s := q.Get("window"); s != "" {
+		var err error
+		window, err = time.ParseDuration(s)
+		if err != nil {
+			http.Error(w, service.ErrInvalidTrendingWindow.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+	}
+
+	first, _ := strconv.Atoi(q.Get("first"))
+	tt, err := h.TrendingTags(r.Context(), window, first)
+	if err == service.ErrInvalidTrendingWindow {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, tt, http.StatusOK)
+}
@@ -15,8 +15,18 @@ func (h *handler) timeline(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	ctx := r.Context()
 	q := r.URL.Query()
+	switch q.Get("mode") {
+	case "", "chronological":
+	case "ranked":
+		h.rankedTimeline(w, r)
+		return
+	default:
+		http.Error(w, "invalid timeline mode", http.StatusUnprocessableEntity)
+		return
+	}
+
+	ctx := r.Context()
 	last, _ := strconv.Atoi(q.Get("last"))
 	before := q.Get("before")
 	tt, err := h.Timeline(ctx, last, before)
@@ -25,6 +35,11 @@ func (h *handler) timeline(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -33,6 +48,30 @@ func (h *handler) timeline(w http.ResponseWriter, r *http.Request) {
 	respond(w, tt, http.StatusOK)
 }
 
+// 推荐时间线，按照分数排序，使用 after 游标往后翻页
+func (h *handler) rankedTimeline(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	first, _ := strconv.Atoi(q.Get("first"))
+	after := q.Get("after")
+	out, err := h.RankedTimeline(r.Context(), first, after)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, out, http.StatusOK)
+}
+
 func (h *handler) timelineItemStream(w http.ResponseWriter, r *http.Request) {
 	f, ok := w.(http.Flusher)
 	if !ok {
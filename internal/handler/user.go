@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/matryer/way"
@@ -50,6 +51,11 @@ func (h *handler) users(w http.ResponseWriter, r *http.Request) {
 	first, _ := strconv.Atoi(q.Get("first"))
 	after := q.Get("after")
 	uu, err := h.Users(r.Context(), search, first, after)
+	if err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -64,6 +70,11 @@ func (h *handler) usernames(w http.ResponseWriter, r *http.Request) {
 	first, _ := strconv.Atoi(q.Get("first"))
 	after := q.Get("after")
 	uu, err := h.Usernames(r.Context(), startingWith, first, after)
+	if err == service.ErrInvalidCursor {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -83,6 +94,12 @@ func (h *handler) user(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err == service.ErrUserNotFound {
+		// 用户改过名的话，重定向到新的用户名
+		if renamed, err := h.RenamedUsername(ctx, username); err == nil {
+			http.Redirect(w, r, "/api/users/"+url.PathEscape(renamed), http.StatusFound)
+			return
+		}
+
 		http.Error(w, err.Error(), http.StatusNotFound)
 		return
 	}
@@ -95,6 +112,92 @@ func (h *handler) user(w http.ResponseWriter, r *http.Request) {
 	respond(w, u, http.StatusOK)
 }
 
+type updateUserInput struct {
+	DisplayName *string
+	Bio         *string
+	Location    *string
+	Website     *string
+	Private     *bool
+}
+
+// 更新当前用户的个人资料，没有传的字段保持不变
+func (h *handler) updateUser(w http.ResponseWriter, r *http.Request) {
+	var in updateUserInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	u, err := h.UpdateUser(r.Context(), in.DisplayName, in.Bio, in.Location, in.Website, in.Private)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidDisplayName ||
+		err == service.ErrInvalidBio ||
+		err == service.ErrInvalidLocation ||
+		err == service.ErrInvalidWebsite {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrUserNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, u, http.StatusOK)
+}
+
+type changeUsernameInput struct {
+	Username string
+}
+
+// 修改当前用户的用户名
+func (h *handler) changeUsername(w http.ResponseWriter, r *http.Request) {
+	var in changeUsernameInput
+	defer r.Body.Close()
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err := h.ChangeUsername(r.Context(), in.Username)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidUsername {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrUserNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrUsernameTaken {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
 //更新用户头像
 func (h *handler) updateAvatar(w http.ResponseWriter, r *http.Request) {
 	r.Body = http.MaxBytesReader(w, r.Body, service.MaxAvatarBytes)
@@ -118,6 +221,42 @@ func (h *handler) updateAvatar(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprint(w, avatarURL)
 }
 
+// 删除用户头像，恢复成默认的 identicon
+func (h *handler) deleteAvatar(w http.ResponseWriter, r *http.Request) {
+	err := h.DeleteAvatar(r.Context())
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) identicon(w http.ResponseWriter, r *http.Request) {
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	b, err := h.Identicon(way.Param(r.Context(), "username"), size)
+	if err == service.ErrInvalidUsername || err == service.ErrInvalidAvatarSize {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "image/png")
+	// Always the same picture for the same username.
+	header.Set("Cache-Control", "public, max-age=604800, immutable")
+	w.Write(b)
+}
+
 // 切换关注状态，也就是未关注的调用此函数之后，会变成关注
 // 已经关注了的，调用此函数之后变成未关注。
 func (h *handler) toggleFollow(w http.ResponseWriter, r *http.Request) {
@@ -140,7 +279,7 @@ func (h *handler) toggleFollow(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err == service.ErrForbiddenFollow {
+	if err == service.ErrForbiddenFollow || err == service.ErrUserBlocked {
 		http.Error(w, err.Error(), http.StatusForbidden)
 		return
 	}
@@ -166,6 +305,11 @@ func (h *handler) followers(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err == service.ErrPrivateAccount {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -187,6 +331,11 @@ func (h *handler) followees(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err == service.ErrPrivateAccount {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
 	if err != nil {
 		respondErr(w, err)
 		return
@@ -194,3 +343,131 @@ func (h *handler) followees(w http.ResponseWriter, r *http.Request) {
 
 	respond(w, uu, http.StatusOK)
 }
+
+// 拉黑或取消拉黑用户username
+func (h *handler) toggleBlock(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	username := way.Param(ctx, "username")
+
+	out, err := h.ToggleBlock(ctx, username)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidUsername {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrUserNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err == service.ErrForbiddenBlock {
+		http.Error(w, err.Error(), http.StatusForbidden)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, out, http.StatusOK)
+}
+
+// 获取当前登录用户拉黑的用户
+func (h *handler) blocks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	first, _ := strconv.Atoi(q.Get("first"))
+	after := q.Get("after")
+	uu, err := h.Blocks(ctx, first, after)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, uu, http.StatusOK)
+}
+
+// 获取发给当前登录用户的关注请求
+func (h *handler) followRequests(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	q := r.URL.Query()
+	first, _ := strconv.Atoi(q.Get("first"))
+	after := q.Get("after")
+	uu, err := h.FollowRequests(ctx, first, after)
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	respond(w, uu, http.StatusOK)
+}
+
+// 同意用户username的关注请求
+func (h *handler) acceptFollowRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	err := h.AcceptFollowRequest(ctx, way.Param(ctx, "username"))
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidUsername {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrFollowRequestNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// 拒绝用户username的关注请求
+func (h *handler) rejectFollowRequest(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	err := h.RejectFollowRequest(ctx, way.Param(ctx, "username"))
+	if err == service.ErrUnauthenticated {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+
+	if err == service.ErrInvalidUsername {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	if err == service.ErrFollowRequestNotFound {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+
+	if err != nil {
+		respondErr(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,582 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+var (
+	// ErrAccountDeletionNotFound denotes a not found account deletion.
+	ErrAccountDeletionNotFound = errors.New("account deletion not found")
+	// ErrAccountBeingDeleted denotes an account with a deletion job running.
+	// Such accounts can only read.
+	ErrAccountBeingDeleted = errors.New("account being deleted")
+)
+
+// AccountDeletion job. Progress goes from 0 to 100.
+type AccountDeletion struct {
+	ID         string     `json:"id"`
+	Status     string     `json:"status"`
+	Step       string     `json:"step"`
+	Progress   int        `json:"progress"`
+	CreatedAt  time.Time  `json:"createdAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+type exportRecord struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// exportSections of the data export. Each query takes the user ID
+// and returns a single json column per row.
+var exportSections = []struct {
+	typ   string
+	query string
+}{
+	{"post", `
+		SELECT json_build_object(
+			'id', id, 'content', content, 'spoilerOf', spoiler_of, 'nsfw', nsfw, 'visibility', visibility,
+			'quotedPostID', quoted_post_id, 'likesCount', likes_count,
+			'commentsCount', comments_count, 'repostsCount', reposts_count, 'createdAt', created_at
+		) FROM posts WHERE user_id = $1 ORDER BY created_at`},
+	{"post_revision", `
+		SELECT json_build_object(
+			'id', post_revisions.id, 'postID', post_revisions.post_id, 'content', post_revisions.content,
+			'spoilerOf', post_revisions.spoiler_of, 'nsfw', post_revisions.nsfw, 'createdAt', post_revisions.created_at
+		) FROM post_revisions
+		INNER JOIN posts ON post_revisions.post_id = posts.id
+		WHERE posts.user_id = $1 ORDER BY post_revisions.created_at`},
+	{"post_media", `
+		SELECT json_build_object(
+			'postID', post_id, 'position', position, 'file', file, 'width', width, 'height', height
+		) FROM post_media
+		WHERE post_id IN (SELECT id FROM posts WHERE user_id = $1) ORDER BY post_id, position`},
+	{"comment", `
+		SELECT json_build_object(
+			'id', id, 'postID', post_id, 'parentID', parent_id, 'content', content,
+			'likesCount', likes_count, 'repliesCount', replies_count, 'createdAt', created_at
+		) FROM comments WHERE user_id = $1 ORDER BY created_at`},
+	{"post_like", `SELECT json_build_object('postID', post_id) FROM post_likes WHERE user_id = $1`},
+	{"comment_like", `SELECT json_build_object('commentID', comment_id) FROM comment_likes WHERE user_id = $1`},
+	{"repost", `
+		SELECT json_build_object('postID', post_id, 'createdAt', created_at)
+		FROM reposts WHERE user_id = $1 ORDER BY created_at`},
+	{"followee", `
+		SELECT json_build_object('username', users.username) FROM follows
+		INNER JOIN users ON follows.followee_id = users.id
+		WHERE follows.follower_id = $1 ORDER BY users.username`},
+	{"follower", `
+		SELECT json_build_object('username', users.username) FROM follows
+		INNER JOIN users ON follows.follower_id = users.id
+		WHERE follows.followee_id = $1 ORDER BY users.username`},
+	{"follow_request", `
+		SELECT json_build_object('username', users.username, 'createdAt', follow_requests.created_at) FROM follow_requests
+		INNER JOIN users ON follow_requests.followee_id = users.id
+		WHERE follow_requests.follower_id = $1 ORDER BY users.username`},
+	{"block", `
+		SELECT json_build_object('username', users.username, 'createdAt', blocks.created_at) FROM blocks
+		INNER JOIN users ON blocks.blocked_id = users.id
+		WHERE blocks.blocker_id = $1 ORDER BY users.username`},
+	{"mute", `
+		SELECT json_build_object(
+			'username', users.username, 'word', mutes.word, 'expiresAt', mutes.expires_at, 'createdAt', mutes.created_at
+		) FROM mutes
+		LEFT JOIN users ON mutes.muted_user_id = users.id
+		WHERE mutes.user_id = $1 ORDER BY mutes.created_at`},
+	{"message", `
+		SELECT json_build_object(
+			'id', id, 'conversationID', conversation_id, 'content', content, 'createdAt', created_at
+		) FROM messages WHERE user_id = $1 ORDER BY created_at`},
+	{"notification", `
+		SELECT json_build_object(
+			'id', id, 'actors', actors, 'type', type, 'postID', post_id, 'readAt', read_at, 'issuedAt', issued_at
+		) FROM notifications WHERE user_id = $1 ORDER BY issued_at`},
+}
+
+// Export all data of the authenticated user as JSON lines.
+// Every line is an object with a "type" and its "data".
+// The first one is the "profile".
+func (s *Service) Export(ctx context.Context) (io.ReadCloser, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	var profile struct {
+		ID          string  `json:"id"`
+		Email       string  `json:"email"`
+		Username    string  `json:"username"`
+		AvatarURL   string  `json:"avatarURL"`
+		DisplayName *string `json:"displayName"`
+		Bio         *string `json:"bio"`
+		Location    *string `json:"location"`
+		Website     *string `json:"website"`
+		Private     bool    `json:"private"`
+	}
+	var avatar sql.NullString
+	err := s.db.QueryRowContext(ctx, `
+		SELECT id, email, username, avatar, display_name, bio, location, website, private
+		FROM users WHERE id = $1`, uid).Scan(
+		&profile.ID,
+		&profile.Email,
+		&profile.Username,
+		&avatar,
+		&profile.DisplayName,
+		&profile.Bio,
+		&profile.Location,
+		&profile.Website,
+		&profile.Private,
+	)
+	if err == sql.ErrNoRows {
+		return nil, ErrUserNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("could not query select user to export: %w", err)
+	}
+
+	profile.AvatarURL = s.avatarURL(profile.Username, avatar)
+	b, err := json.Marshal(profile)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal exported profile: %w", err)
+	}
+
+	pr, pw := io.Pipe()
+	go func() {
+		enc := json.NewEncoder(pw)
+		if err := enc.Encode(exportRecord{Type: "profile", Data: b}); err != nil {
+			pw.CloseWithError(err)
+			return
+		}
+
+		for _, section := range exportSections {
+			if err := s.exportSection(ctx, enc, uid, section.typ, section.query); err != nil {
+				log.Printf("could not export %s: %v\n", section.typ, err)
+				pw.CloseWithError(err)
+				return
+			}
+		}
+
+		pw.Close()
+	}()
+
+	return pr, nil
+}
+
+func (s *Service) exportSection(ctx context.Context, enc *json.Encoder, uid, typ, query string) error {
+	rows, err := s.db.QueryContext(ctx, query, uid)
+	if err != nil {
+		return fmt.Errorf("could not query select %s export: %w", typ, err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var data []byte
+		if err = rows.Scan(&data); err != nil {
+			return fmt.Errorf("could not scan %s export: %w", typ, err)
+		}
+
+		if err = enc.Encode(exportRecord{Type: typ, Data: data}); err != nil {
+			return err
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate %s export rows: %w", typ, err)
+	}
+
+	return nil
+}
+
+// accountDeletionSteps run in order. Each one can be run again after a crash
+// without breaking counters.
+var accountDeletionSteps = []struct {
+	name string
+	run  func(s *Service, ctx context.Context, uid string) error
+}{
+	{"follows", (*Service).deleteAccountFollows},
+	{"likes", (*Service).deleteAccountLikes},
+	{"reposts", (*Service).deleteAccountReposts},
+	{"posts", (*Service).deleteAccountPosts},
+	{"comments", (*Service).deleteAccountComments},
+	{"notifications", (*Service).deleteAccountNotifications},
+	{"user", (*Service).deleteAccountUser},
+}
+
+// DeleteAccount of the authenticated user. The deletion runs in the background;
+// use the returned job to check its progress.
+// If there is already one running, that one is returned.
+func (s *Service) DeleteAccount(ctx context.Context) (AccountDeletion, error) {
+	var d AccountDeletion
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return d, ErrUnauthenticated
+	}
+
+	var created bool
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		created = false
+		_, err := accountDeletion(ctx, tx, uid, true)
+		if err == nil {
+			return nil
+		}
+
+		if err != ErrAccountDeletionNotFound {
+			return err
+		}
+
+		query := "INSERT INTO account_deletions (user_id) VALUES ($1)"
+		if _, err = tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not insert account deletion: %w", err)
+		}
+
+		created = true
+		return nil
+	})
+	if err != nil {
+		return d, err
+	}
+
+	d, err = accountDeletion(ctx, s.db, uid, false)
+	if err != nil {
+		return d, err
+	}
+
+	if created {
+		go s.runAccountDeletion(d.ID, uid)
+	}
+
+	return d, nil
+}
+
+// AccountDeletion job of the authenticated user. The latest one.
+func (s *Service) AccountDeletion(ctx context.Context) (AccountDeletion, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return AccountDeletion{}, ErrUnauthenticated
+	}
+
+	return accountDeletion(ctx, s.db, uid, false)
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func accountDeletion(ctx context.Context, db queryRower, uid string, pendingOnly bool) (AccountDeletion, error) {
+	var d AccountDeletion
+	var failed bool
+	query := `
+		SELECT id, step, progress, failure IS NOT NULL, created_at, finished_at
+		FROM account_deletions
+		WHERE user_id = $1`
+	if pendingOnly {
+		query += " AND finished_at IS NULL AND failure IS NULL"
+	}
+	query += " ORDER BY created_at DESC LIMIT 1"
+	err := db.QueryRowContext(ctx, query, uid).Scan(&d.ID, &d.Step, &d.Progress, &failed, &d.CreatedAt, &d.FinishedAt)
+	if err == sql.ErrNoRows {
+		return d, ErrAccountDeletionNotFound
+	}
+
+	if err != nil {
+		return d, fmt.Errorf("could not query select account deletion: %w", err)
+	}
+
+	switch {
+	case failed:
+		d.Status = "failed"
+	case d.FinishedAt != nil:
+		d.Status = "done"
+	default:
+		d.Status = "running"
+	}
+	return d, nil
+}
+
+// resumeAccountDeletions left unfinished by a previous process.
+func (s *Service) resumeAccountDeletions() {
+	ctx := context.Background()
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, user_id FROM account_deletions
+		WHERE finished_at IS NULL AND failure IS NULL`)
+	if err != nil {
+		log.Printf("could not query select pending account deletions: %v\n", err)
+		return
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var id, uid string
+		if err = rows.Scan(&id, &uid); err != nil {
+			log.Printf("could not scan pending account deletion: %v\n", err)
+			return
+		}
+
+		go s.runAccountDeletion(id, uid)
+	}
+
+	if err = rows.Err(); err != nil {
+		log.Printf("could not iterate pending account deletion rows: %v\n", err)
+	}
+}
+
+func (s *Service) runAccountDeletion(id, uid string) {
+	// Steps reuse the regular service methods acting as the user being deleted.
+	ctx := context.WithValue(context.Background(), KeyAuthUserID, uid)
+	for i, step := range accountDeletionSteps {
+		if _, err := s.db.ExecContext(ctx, `
+			UPDATE account_deletions SET step = $1, progress = $2 WHERE id = $3`,
+			step.name, i*100/len(accountDeletionSteps), id); err != nil {
+			log.Printf("could not update account deletion progress: %v\n", err)
+		}
+
+		if err := step.run(s, ctx, uid); err != nil {
+			log.Printf("could not delete account %s %s: %v\n", uid, step.name, err)
+			if _, err := s.db.ExecContext(ctx, "UPDATE account_deletions SET failure = $1 WHERE id = $2", err.Error(), id); err != nil {
+				log.Printf("could not update failed account deletion: %v\n", err)
+			}
+			return
+		}
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE account_deletions SET step = 'done', progress = 100, finished_at = now()
+		WHERE id = $1`, id); err != nil {
+		log.Printf("could not update finished account deletion: %v\n", err)
+	}
+}
+
+func (s *Service) deleteAccountFollows(ctx context.Context, uid string) error {
+	return crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		return deleteFollows(ctx, tx, uid)
+	})
+}
+
+// deleteFollows from and to the given user, updating the counters of the other side.
+func deleteFollows(ctx context.Context, tx *sql.Tx, uid string) error {
+	query := `
+		UPDATE users SET followers_count = followers_count - 1
+		WHERE id IN (SELECT followee_id FROM follows WHERE follower_id = $1)`
+	if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+		return fmt.Errorf("could not decrement followers count: %w", err)
+	}
+
+	query = `
+		UPDATE users SET followees_count = followees_count - 1
+		WHERE id IN (SELECT follower_id FROM follows WHERE followee_id = $1)`
+	if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+		return fmt.Errorf("could not decrement followees count: %w", err)
+	}
+
+	query = "DELETE FROM follows WHERE follower_id = $1 OR followee_id = $1"
+	if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+		return fmt.Errorf("could not delete follows: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) deleteAccountLikes(ctx context.Context, uid string) error {
+	return crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		query := `
+			UPDATE posts SET likes_count = likes_count - 1
+			WHERE id IN (SELECT post_id FROM post_likes WHERE user_id = $1)`
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not decrement post likes count: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM post_likes WHERE user_id = $1", uid); err != nil {
+			return fmt.Errorf("could not delete post likes: %w", err)
+		}
+
+		query = `
+			UPDATE comments SET likes_count = likes_count - 1
+			WHERE id IN (SELECT comment_id FROM comment_likes WHERE user_id = $1)`
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not decrement comment likes count: %w", err)
+		}
+
+		if _, err := tx.ExecContext(ctx, "DELETE FROM comment_likes WHERE user_id = $1", uid); err != nil {
+			return fmt.Errorf("could not delete comment likes: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Service) deleteAccountReposts(ctx context.Context, uid string) error {
+	return s.eachAccountRow(ctx, "SELECT post_id FROM reposts WHERE user_id = $1 LIMIT 100", uid, func(postID string) error {
+		_, err := s.Unrepost(ctx, postID)
+		return err
+	})
+}
+
+func (s *Service) deleteAccountPosts(ctx context.Context, uid string) error {
+	return s.eachAccountRow(ctx, "SELECT id FROM posts WHERE user_id = $1 LIMIT 100", uid, func(postID string) error {
+		if err := s.DeletePost(ctx, postID); err != nil && err != ErrPostNotFound {
+			return err
+		}
+		return nil
+	})
+}
+
+// deleteAccountComments left on someone else's posts.
+func (s *Service) deleteAccountComments(ctx context.Context, uid string) error {
+	return s.eachAccountRow(ctx, "SELECT id FROM comments WHERE user_id = $1 LIMIT 100", uid, func(commentID string) error {
+		// Deleting a comment takes its replies with it.
+		if err := s.DeleteComment(ctx, commentID); err != nil && err != ErrCommentNotFound {
+			return err
+		}
+		return nil
+	})
+}
+
+func (s *Service) deleteAccountNotifications(ctx context.Context, uid string) error {
+	return crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM notifications WHERE user_id = $1", uid); err != nil {
+			return fmt.Errorf("could not delete notifications: %w", err)
+		}
+
+		query := `
+			UPDATE notifications SET actors = array_remove(actors, (SELECT username FROM users WHERE id = $1))
+			WHERE (SELECT username FROM users WHERE id = $1) = ANY(actors)`
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not remove notification actor: %w", err)
+		}
+
+		query = "DELETE FROM notifications WHERE array_length(actors, 1) IS NULL"
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("could not delete notifications without actors: %w", err)
+		}
+
+		return nil
+	})
+}
+
+func (s *Service) deleteAccountUser(ctx context.Context, uid string) error {
+	var avatar sql.NullString
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		// Others could have followed the user since the follows step.
+		if err := deleteFollows(ctx, tx, uid); err != nil {
+			return err
+		}
+
+		for _, table := range []string{
+			"notifications",
+			"post_subscriptions",
+			"post_mentions",
+			"timeline",
+			"timeline_hides",
+			"ranked_timeline",
+			"verification_codes",
+			"username_history",
+		} {
+			query := fmt.Sprintf("DELETE FROM %s WHERE user_id = $1", table)
+			if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+				return fmt.Errorf("could not delete %s: %w", table, err)
+			}
+		}
+
+		query := "DELETE FROM blocks WHERE blocker_id = $1 OR blocked_id = $1"
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not delete blocks: %w", err)
+		}
+
+		query = "DELETE FROM mutes WHERE user_id = $1 OR muted_user_id = $1"
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not delete mutes: %w", err)
+		}
+
+		query = "DELETE FROM follow_requests WHERE follower_id = $1 OR followee_id = $1"
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not delete follow requests: %w", err)
+		}
+
+		query = `
+			UPDATE conversations SET last_message_id = NULL
+			WHERE last_message_id IN (SELECT id FROM messages WHERE user_id = $1)`
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not unset conversations last message: %w", err)
+		}
+
+		query = "DELETE FROM messages WHERE user_id = $1"
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not delete messages: %w", err)
+		}
+
+		query = "DELETE FROM conversation_members WHERE user_id = $1"
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not delete conversation memberships: %w", err)
+		}
+
+		query = "DELETE FROM users WHERE id = $1 RETURNING avatar"
+		err := tx.QueryRowContext(ctx, query, uid).Scan(&avatar)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not delete user: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if avatar.Valid {
+		s.removeAvatar(avatar.String)
+	}
+
+	return nil
+}
+
+// eachAccountRow runs fn for every id returned by the given query
+// until it returns no more rows. fn is expected to make the row go away.
+func (s *Service) eachAccountRow(ctx context.Context, query, uid string, fn func(id string) error) error {
+	for {
+		rows, err := s.db.QueryContext(ctx, query, uid)
+		if err != nil {
+			return fmt.Errorf("could not query select account rows: %w", err)
+		}
+
+		var ids []string
+		for rows.Next() {
+			var id string
+			if err = rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("could not scan account row: %w", err)
+			}
+
+			ids = append(ids, id)
+		}
+
+		rows.Close()
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("could not iterate account rows: %w", err)
+		}
+
+		if len(ids) == 0 {
+			return nil
+		}
+
+		for _, id := range ids {
+			if err = fn(id); err != nil {
+				return err
+			}
+		}
+	}
+}
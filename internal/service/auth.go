@@ -29,6 +29,8 @@ var (
 	ErrUnimplemented = errors.New("unimplemented")
 	// ErrUnauthenticated denotes no authenticated user in context.
 	ErrUnauthenticated = errors.New("unauthenticated")
+	// ErrPermissionDenied denotes an authenticated user trying to act on a resource owned by someone else.
+	ErrPermissionDenied = errors.New("permission denied")
 	// ErrInvalidRedirectURI denotes an invalid redirect URI.
 	ErrInvalidRedirectURI = errors.New("invalid redirect URI")
 	// ErrInvalidToken denotes an invalid token.
@@ -139,7 +141,7 @@ func (s *Service) AuthURI(ctx context.Context, verificationCode, redirectURI str
 	var uid string
 	var createdAt time.Time
 	err = s.db.QueryRowContext(ctx, `
-		DELETE FROM verification_codes WHERE id = $1
+		DELETE FROM verification_codes WHERE id = $1 AND email IS NULL
 		RETURNING user_id, created_at`, verificationCode).Scan(&uid, &createdAt)
 	if err == sql.ErrNoRows {
 		return "", ErrVerificationCodeNotFound
@@ -190,7 +192,8 @@ func (s *Service) DevLogin(ctx context.Context, email string) (DevLoginOutput, e
 		return out, fmt.Errorf("could not query select user: %w", err)
 	}
 
-	out.User.AvatarURL = s.avatarURL(avatar)
+	out.User.AvatarURL = s.avatarURL(out.User.Username, avatar)
+	out.User.AvatarURLs = s.avatarURLs(out.User.Username, avatar)
 
 	out.Token, err = s.codec().EncodeToString(out.User.ID) //生成Token，以用户的ID为key
 	if err != nil {
@@ -0,0 +1,299 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/gif"
+	"io"
+	"log"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/disintegration/imaging"
+	gonanoid "github.com/matoous/go-nanoid"
+)
+
+// avatarSize is the size of the main avatar variant; the one in User.AvatarURL.
+const avatarSize = 400
+
+// avatarSizes in which every avatar gets generated.
+var avatarSizes = []int{48, 96, avatarSize}
+
+// writeAvatar stores every size variant of the given image returning the avatar name.
+// Animated gifs keep their animation in the main variant only;
+// the rest of variants take the first frame.
+func (s *Service) writeAvatar(ctx context.Context, b []byte, format string) (avatar string, err error) {
+	name, err := gonanoid.Nanoid() //生存随机ID
+	if err != nil {
+		return "", fmt.Errorf("could not generate avatar filename: %w", err)
+	}
+
+	var img image.Image
+	var anim *gif.GIF
+	if format == "gif" {
+		g, err := gif.DecodeAll(bytes.NewReader(b))
+		if err != nil {
+			return "", fmt.Errorf("could not decode gif avatar: %w", err)
+		}
+
+		img = gifFirstFrame(g)
+		if len(g.Image) > 1 {
+			anim = g
+		}
+	} else {
+		img, err = imaging.Decode(bytes.NewReader(b), imaging.AutoOrientation(true))
+		if err != nil {
+			return "", fmt.Errorf("could not decode avatar: %w", err)
+		}
+	}
+
+	ext := imageExt(img, format)
+	avatar = name + ext
+	if anim != nil {
+		avatar = name + ".gif"
+	}
+
+	var written []string
+	defer func() {
+		if err != nil {
+			for _, blob := range written {
+				s.deleteBlob(blob)
+			}
+		}
+	}()
+
+	for _, size := range avatarSizes {
+		blob := avatarBlob(avatarVariant(avatar, size))
+		if size == avatarSize && anim != nil {
+			g := resizeGIF(anim, size)
+			err = s.putImage(ctx, blob, "image/gif", func(w io.Writer) error { return gif.EncodeAll(w, g) })
+		} else {
+			err = s.putAvatarVariant(ctx, avatar, img, size)
+		}
+		if err != nil {
+			return "", err
+		}
+
+		written = append(written, blob)
+	}
+
+	return avatar, nil
+}
+
+// putAvatarVariant stores the given size variant of the avatar out of img.
+func (s *Service) putAvatarVariant(ctx context.Context, avatar string, img image.Image, size int) error {
+	variant := avatarVariant(avatar, size)
+	ext := path.Ext(variant)
+	// 将 img 进行转换，转换为 size x size 的图片。
+	resized := imaging.Fill(img, size, size, imaging.Center, imaging.CatmullRom)
+	return s.putImage(ctx, avatarBlob(variant), imageContentType(ext), func(w io.Writer) error {
+		return encodeImage(w, resized, ext)
+	})
+}
+
+// backfillAvatarVariants generates the smaller size variants
+// of the avatars stored before variants existed.
+// Meant to be run in the background on start.
+func (s *Service) backfillAvatarVariants() {
+	ctx := context.Background()
+	rows, err := s.db.QueryContext(ctx, "SELECT id, avatar FROM users WHERE avatar IS NOT NULL AND NOT avatar_variants")
+	if err != nil {
+		log.Printf("could not query select avatars without variants: %v\n", err)
+		return
+	}
+
+	type userAvatar struct{ userID, avatar string }
+	var uu []userAvatar
+	for rows.Next() {
+		var u userAvatar
+		if err = rows.Scan(&u.userID, &u.avatar); err != nil {
+			rows.Close()
+			log.Printf("could not scan avatar without variants: %v\n", err)
+			return
+		}
+
+		uu = append(uu, u)
+	}
+
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		log.Printf("could not iterate avatars without variants: %v\n", err)
+		return
+	}
+
+	for _, u := range uu {
+		if err := s.writeAvatarVariants(ctx, u.avatar); err != nil {
+			log.Printf("could not backfill avatar %q variants: %v\n", u.avatar, err)
+			continue
+		}
+
+		// The avatar could have been changed meanwhile.
+		query := "UPDATE users SET avatar_variants = true WHERE id = $1 AND avatar = $2"
+		if _, err := s.db.ExecContext(ctx, query, u.userID, u.avatar); err != nil {
+			log.Printf("could not update avatar variants: %v\n", err)
+		}
+	}
+}
+
+// writeAvatarVariants out of the already stored main variant.
+func (s *Service) writeAvatarVariants(ctx context.Context, avatar string) error {
+	r, err := s.store.Get(ctx, avatarBlob(avatar))
+	if err != nil {
+		return fmt.Errorf("could not get avatar: %w", err)
+	}
+
+	defer r.Close()
+
+	var img image.Image
+	if path.Ext(avatar) == ".gif" {
+		g, err := gif.DecodeAll(r)
+		if err != nil {
+			return fmt.Errorf("could not decode gif avatar: %w", err)
+		}
+
+		img = gifFirstFrame(g)
+	} else {
+		img, err = imaging.Decode(r)
+		if err != nil {
+			return fmt.Errorf("could not decode avatar: %w", err)
+		}
+	}
+
+	for _, size := range avatarSizes {
+		if size == avatarSize {
+			continue
+		}
+
+		if err = s.putAvatarVariant(ctx, avatar, img, size); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// removeAvatar deletes every size variant of the given avatar.
+func (s *Service) removeAvatar(avatar string) {
+	for _, size := range avatarSizes {
+		s.deleteBlob(avatarBlob(avatarVariant(avatar, size)))
+	}
+}
+
+// avatarVariant file name. The main variant is the avatar itself, so avatars
+// stored before variants existed keep working; backfillAvatarVariants generates the rest.
+// Smaller variants are never animated.
+func avatarVariant(avatar string, size int) string {
+	if size == avatarSize {
+		return avatar
+	}
+
+	ext := path.Ext(avatar)
+	name := strings.TrimSuffix(avatar, ext)
+	if ext == ".gif" {
+		ext = ".png"
+	}
+	return name + "_" + strconv.Itoa(size) + ext
+}
+
+func avatarBlob(avatar string) string {
+	return "avatars/" + avatar
+}
+
+// avatarURL of the main variant. Users without avatar get their identicon.
+func (s *Service) avatarURL(username string, avatar sql.NullString) string {
+	if !avatar.Valid {
+		return s.identiconURL(username, avatarSize)
+	}
+
+	return s.store.URL(avatarBlob(avatar.String))
+}
+
+// avatarURLs by size. Suitable for a srcset.
+func (s *Service) avatarURLs(username string, avatar sql.NullString) map[int]string {
+	urls := make(map[int]string, len(avatarSizes))
+	for _, size := range avatarSizes {
+		if avatar.Valid {
+			urls[size] = s.store.URL(avatarBlob(avatarVariant(avatar.String, size)))
+		} else {
+			urls[size] = s.identiconURL(username, size)
+		}
+	}
+	return urls
+}
+
+// gifFirstFrame drawn over the whole gif canvas.
+func gifFirstFrame(g *gif.GIF) image.Image {
+	frame := g.Image[0]
+	if frame.Bounds() == image.Rect(0, 0, g.Config.Width, g.Config.Height) {
+		return frame
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+	return canvas
+}
+
+// resizeGIF fills every frame into a size x size square.
+func resizeGIF(g *gif.GIF, size int) *gif.GIF {
+	return transformGIF(g, func(img image.Image) *image.NRGBA {
+		return imaging.Fill(img, size, size, imaging.Center, imaging.CatmullRom)
+	})
+}
+
+// fitGIF scales down every frame to fit within size x size keeping the aspect ratio.
+func fitGIF(g *gif.GIF, size int) *gif.GIF {
+	return transformGIF(g, func(img image.Image) *image.NRGBA {
+		return imaging.Fit(img, size, size, imaging.CatmullRom)
+	})
+}
+
+// transformGIF applies fn to every frame.
+// Frames are composed over the full canvas first, honoring their disposal method,
+// so the result only contains full frames.
+func transformGIF(g *gif.GIF, fn func(image.Image) *image.NRGBA) *gif.GIF {
+	out := &gif.GIF{LoopCount: g.LoopCount}
+	canvas := image.NewRGBA(image.Rect(0, 0, g.Config.Width, g.Config.Height))
+	for i, frame := range g.Image {
+		var disposal byte
+		if i < len(g.Disposal) {
+			disposal = g.Disposal[i]
+		}
+
+		var prev *image.RGBA
+		if disposal == gif.DisposalPrevious {
+			prev = image.NewRGBA(canvas.Bounds())
+			copy(prev.Pix, canvas.Pix)
+		}
+
+		draw.Draw(canvas, frame.Bounds(), frame, frame.Bounds().Min, draw.Over)
+		resized := fn(canvas)
+		dst := image.NewPaletted(resized.Bounds(), frame.Palette)
+		draw.FloydSteinberg.Draw(dst, dst.Bounds(), resized, image.Point{})
+
+		var delay int
+		if i < len(g.Delay) {
+			delay = g.Delay[i]
+		}
+		out.Image = append(out.Image, dst)
+		out.Delay = append(out.Delay, delay)
+		out.Disposal = append(out.Disposal, gif.DisposalBackground)
+
+		switch disposal {
+		case gif.DisposalBackground:
+			draw.Draw(canvas, frame.Bounds(), image.Transparent, image.Point{}, draw.Src)
+		case gif.DisposalPrevious:
+			canvas = prev
+		}
+	}
+
+	if len(out.Image) != 0 {
+		bounds := out.Image[0].Bounds()
+		out.Config = image.Config{Width: bounds.Dx(), Height: bounds.Dy()}
+	}
+	return out
+}
@@ -0,0 +1,195 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+var (
+	// ErrForbiddenBlock denotes a forbidden block. Like blocking yourself.
+	ErrForbiddenBlock = errors.New("forbidden block")
+	// ErrUserBlocked denotes an interaction between two users where one blocked the other.
+	ErrUserBlocked = errors.New("user blocked")
+)
+
+// ToggleBlockOutput response.
+type ToggleBlockOutput struct {
+	Blocked bool `json:"blocked"`
+}
+
+// ToggleBlock between two users.
+// Blocking removes any follow between them in both directions
+// and the posts each one got in the timeline of the other.
+func (s *Service) ToggleBlock(ctx context.Context, username string) (ToggleBlockOutput, error) {
+	var out ToggleBlockOutput
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	username = strings.TrimSpace(username)
+	if !reUsername.MatchString(username) {
+		return out, ErrInvalidUsername
+	}
+
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		var blockedID string
+		query := "SELECT id FROM users WHERE username = $1"
+		err := tx.QueryRowContext(ctx, query, username).Scan(&blockedID)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select user id from username: %w", err)
+		}
+
+		if blockedID == uid {
+			return ErrForbiddenBlock
+		}
+
+		query = "DELETE FROM blocks WHERE blocker_id = $1 AND blocked_id = $2"
+		result, err := tx.ExecContext(ctx, query, uid, blockedID)
+		if err != nil {
+			return fmt.Errorf("could not delete block: %w", err)
+		}
+
+		if n, _ := result.RowsAffected(); n != 0 {
+			out.Blocked = false
+			return nil
+		}
+
+		query = "INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)"
+		if _, err = tx.ExecContext(ctx, query, uid, blockedID); err != nil {
+			return fmt.Errorf("could not insert block: %w", err)
+		}
+
+		for _, follow := range [][2]string{{uid, blockedID}, {blockedID, uid}} {
+			query = "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2"
+			result, err := tx.ExecContext(ctx, query, follow[0], follow[1])
+			if err != nil {
+				return fmt.Errorf("could not delete follow: %w", err)
+			}
+
+			if n, _ := result.RowsAffected(); n == 0 {
+				continue
+			}
+
+			query = "UPDATE users SET followees_count = followees_count - 1 WHERE id = $1"
+			if _, err = tx.ExecContext(ctx, query, follow[0]); err != nil {
+				return fmt.Errorf("could not decrement followees count: %w", err)
+			}
+
+			query = "UPDATE users SET followers_count = followers_count - 1 WHERE id = $1"
+			if _, err = tx.ExecContext(ctx, query, follow[1]); err != nil {
+				return fmt.Errorf("could not decrement followers count: %w", err)
+			}
+		}
+
+		query = `
+			DELETE FROM follow_requests
+			WHERE (follower_id = $1 AND followee_id = $2) OR (follower_id = $2 AND followee_id = $1)`
+		if _, err = tx.ExecContext(ctx, query, uid, blockedID); err != nil {
+			return fmt.Errorf("could not delete follow requests: %w", err)
+		}
+
+		query = `
+			DELETE FROM timeline
+			WHERE (user_id = $1 AND post_id IN (SELECT id FROM posts WHERE user_id = $2))
+				OR (user_id = $2 AND post_id IN (SELECT id FROM posts WHERE user_id = $1))`
+		if _, err = tx.ExecContext(ctx, query, uid, blockedID); err != nil {
+			return fmt.Errorf("could not delete timeline items of blocked user: %w", err)
+		}
+
+		out.Blocked = true
+		return nil
+	})
+	if err != nil {
+		return out, err
+	}
+
+	return out, nil
+}
+
+// Blocks of the authenticated user in ascending order with forward pagination.
+func (s *Service) Blocks(ctx context.Context, first int, after string) ([]UserProfile, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	first = normalizePageSize(first)
+	after = strings.TrimSpace(after)
+	query, args, err := buildQuery(`
+		SELECT username, avatar, followers_count, followees_count
+		, display_name, bio, location, website
+		FROM blocks
+		INNER JOIN users ON blocks.blocked_id = users.id
+		WHERE blocks.blocker_id = @uid
+		{{if .after}}AND username > @after{{end}}
+		ORDER BY username ASC
+		LIMIT @first`, map[string]interface{}{
+		"uid":   uid,
+		"first": first,
+		"after": after,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build blocks sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select blocks: %w", err)
+	}
+
+	defer rows.Close()
+	uu := make([]UserProfile, 0, first)
+	for rows.Next() {
+		var u UserProfile
+		var avatar sql.NullString
+		dest := []interface{}{
+			&u.Username,
+			&avatar,
+			&u.FollowersCount,
+			&u.FolloweesCount,
+			&u.DisplayName,
+			&u.Bio,
+			&u.Location,
+			&u.Website,
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan block: %w", err)
+		}
+
+		u.Blocked = true
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		uu = append(uu, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate block rows: %w", err)
+	}
+
+	return uu, nil
+}
+
+// blockedBetween reports whether any of the two users blocked the other.
+func blockedBetween(ctx context.Context, db queryRower, userID, otherUserID string) (bool, error) {
+	var blocked bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2) OR (blocker_id = $2 AND blocked_id = $1)
+		)`
+	if err := db.QueryRowContext(ctx, query, userID, otherUserID).Scan(&blocked); err != nil {
+		return false, fmt.Errorf("could not query select block existence: %w", err)
+	}
+
+	return blocked, nil
+}
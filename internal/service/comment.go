@@ -24,19 +24,30 @@ var (
 
 // Comment model.
 type Comment struct {
-	ID         string    `json:"id"`
-	UserID     string    `json:"-"`
-	PostID     string    `json:"-"`
-	Content    string    `json:"content"`
-	LikesCount int       `json:"likesCount"`
-	CreatedAt  time.Time `json:"createdAt"`
-	User       *User     `json:"user,omitempty"`
-	Mine       bool      `json:"mine"`
-	Liked      bool      `json:"liked"`
+	ID           string    `json:"id"`
+	UserID       string    `json:"-"`
+	PostID       string    `json:"-"`
+	ParentID     *string   `json:"parentID,omitempty"`
+	Content      string    `json:"content"`
+	LikesCount   int       `json:"likesCount"`
+	RepliesCount int       `json:"repliesCount"`
+	CreatedAt    time.Time `json:"createdAt"`
+	User         *User     `json:"user,omitempty"`
+	Mine         bool      `json:"mine"`
+	Liked        bool      `json:"liked"`
+	Deleted      bool      `json:"deleted,omitempty"` // set on stream events telling the client to drop the comment
+}
+
+// CommentsOutput response.
+type CommentsOutput struct {
+	Items []Comment `json:"items"`
+	PageInfo
 }
 
 // CreateComment on a post.
-func (s *Service) CreateComment(ctx context.Context, postID string, content string) (Comment, error) {
+// When parentID is given the comment is a reply. Replies to replies are attached to the top-level
+// comment so threads stay one level deep.
+func (s *Service) CreateComment(ctx context.Context, postID string, content string, parentID *string) (Comment, error) {
 	var c Comment
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
@@ -47,16 +58,91 @@ func (s *Service) CreateComment(ctx context.Context, postID string, content stri
 		return c, ErrInvalidPostID
 	}
 
+	if parentID != nil && !reUUID.MatchString(*parentID) {
+		return c, ErrInvalidCommentID
+	}
+
 	content = smartTrim(content)
 	if content == "" || utf8.RuneCountInString(content) > 480 {
 		return c, ErrInvalidContent
 	}
 
-	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
-		query := `
-			INSERT INTO comments (user_id, post_id, content) VALUES ($1, $2, $3)
+	hidden, err := s.hiddenAccount(ctx, ownerByPost, postID)
+	if err != nil {
+		return c, err
+	}
+
+	if hidden {
+		return c, ErrPrivateAccount
+	}
+
+	hidden, err = s.hiddenPost(ctx, postByID, postID)
+	if err != nil {
+		return c, err
+	}
+
+	if hidden {
+		return c, ErrPostNotFound
+	}
+
+	var parentUserID string
+	err = crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		var authorID string
+		query := "SELECT user_id FROM posts WHERE id = $1"
+		err := tx.QueryRowContext(ctx, query, postID).Scan(&authorID)
+		if err == sql.ErrNoRows {
+			return ErrPostNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select post author: %w", err)
+		}
+
+		blocked, err := blockedBetween(ctx, tx, uid, authorID)
+		if err != nil {
+			return err
+		}
+
+		if blocked {
+			return ErrUserBlocked
+		}
+
+		c.ParentID = nil
+		if parentID != nil {
+			var rootID string
+			query := "SELECT user_id, COALESCE(parent_id, id) FROM comments WHERE id = $1 AND post_id = $2"
+			err := tx.QueryRowContext(ctx, query, *parentID, postID).Scan(&parentUserID, &rootID)
+			if err == sql.ErrNoRows {
+				return ErrCommentNotFound
+			}
+
+			if err != nil {
+				return fmt.Errorf("could not query select parent comment: %w", err)
+			}
+
+			if parentUserID != authorID {
+				blocked, err := blockedBetween(ctx, tx, uid, parentUserID)
+				if err != nil {
+					return err
+				}
+
+				if blocked {
+					return ErrUserBlocked
+				}
+			}
+
+			query = "UPDATE comments SET replies_count = replies_count + 1 WHERE id = $1"
+			if _, err = tx.ExecContext(ctx, query, rootID); err != nil {
+				return fmt.Errorf("could not update and increment comment replies count: %w", err)
+			}
+
+			c.ParentID = &rootID
+		}
+
+		query = `
+			INSERT INTO comments (user_id, post_id, parent_id, content) VALUES ($1, $2, $3, $4)
 			RETURNING id, created_at`
-		err := tx.QueryRowContext(ctx, query, uid, postID, content).Scan(&c.ID, &c.CreatedAt)
+		err = tx.QueryRowContext(ctx, query, uid, postID, c.ParentID, content).Scan(&c.ID, &c.CreatedAt)
 		if isForeignKeyViolation(err) {
 			return ErrPostNotFound
 		}
@@ -70,6 +156,10 @@ func (s *Service) CreateComment(ctx context.Context, postID string, content stri
 		c.Content = content
 		c.Mine = true
 
+		if err = insertTags(ctx, tx, postID, &c.ID, collectTags(content)); err != nil {
+			return err
+		}
+
 		query = `
 			INSERT INTO post_subscriptions (user_id, post_id) VALUES ($1, $2)
 			ON CONFLICT (user_id, post_id) DO NOTHING`
@@ -88,12 +178,12 @@ func (s *Service) CreateComment(ctx context.Context, postID string, content stri
 		return c, err
 	}
 
-	go s.commentCreated(c)
+	go s.commentCreated(c, parentUserID)
 
 	return c, nil
 }
 
-func (s *Service) commentCreated(c Comment) {
+func (s *Service) commentCreated(c Comment, parentUserID string) {
 	u, err := s.userByID(context.Background(), c.UserID)
 	if err != nil {
 		log.Printf("could not fetch comment user: %v\n", err)
@@ -104,24 +194,51 @@ func (s *Service) commentCreated(c Comment) {
 	c.Mine = false
 
 	go s.notifyComment(c)
-	go s.notifyCommentMention(c)
+	go s.notifyCommentMention(c, collectMentions(c.Content))
+	if c.ParentID != nil {
+		go s.notifyCommentReply(c, parentUserID)
+	}
 	go s.broadcastComment(c)
 }
 
 // Comments from a post in descending order with backward pagination.
-func (s *Service) Comments(ctx context.Context, postID string, last int, before string) ([]Comment, error) {
+// Only top-level comments are returned; replies are listed with CommentReplies.
+func (s *Service) Comments(ctx context.Context, postID string, last int, before string) (CommentsOutput, error) {
+	var out CommentsOutput
 	if !reUUID.MatchString(postID) {
-		return nil, ErrInvalidPostID
+		return out, ErrInvalidPostID
+	}
+
+	var cursor timeCursor
+	if before != "" {
+		var err error
+		if cursor, err = decodeTimeCursor(before); err != nil {
+			return out, err
+		}
+	}
+
+	hidden, err := s.hiddenAccount(ctx, ownerByPost, postID)
+	if err != nil {
+		return out, err
+	}
+
+	if hidden {
+		return out, ErrPrivateAccount
+	}
+
+	hidden, err = s.hiddenPost(ctx, postByID, postID)
+	if err != nil {
+		return out, err
 	}
 
-	if before != "" && !reUUID.MatchString(before) {
-		return nil, ErrInvalidCommentID
+	if hidden {
+		return out, ErrPostNotFound
 	}
 
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	last = normalizePageSize(last)
 	query, args, err := buildQuery(`
-		SELECT comments.id, content, likes_count, created_at, username, avatar
+		SELECT comments.id, content, likes_count, replies_count, comments.created_at, username, avatar
 		{{if .auth}}
 		, comments.user_id = @uid AS mine
 		, likes.user_id IS NOT NULL AS liked
@@ -133,49 +250,162 @@ func (s *Service) Comments(ctx context.Context, postID string, last int, before
 			ON likes.comment_id = comments.id AND likes.user_id = @uid
 		{{end}}
 		WHERE comments.post_id = @post_id
-		{{if .before}}AND comments.id < @before{{end}}
-		ORDER BY created_at DESC
-		LIMIT @last`, map[string]interface{}{
-		"auth":    auth,
-		"uid":     uid,
-		"post_id": postID,
-		"before":  before,
-		"last":    last,
+			AND comments.parent_id IS NULL
+		{{if .before}}AND (comments.created_at, comments.id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+		ORDER BY comments.created_at DESC, comments.id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"auth":       auth,
+		"uid":        uid,
+		"post_id":    postID,
+		"before":     before != "",
+		"cursorTime": cursor.Time,
+		"cursorID":   cursor.ID,
+		"limit":      last + 1,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("could not build comments sql query: %w", err)
+		return out, fmt.Errorf("could not build comments sql query: %w", err)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select comments: %w", err)
+		return out, fmt.Errorf("could not query select comments: %w", err)
 	}
 
 	defer rows.Close()
 
-	cc := make([]Comment, 0, last)
+	cc := make([]Comment, 0, last+1)
 	for rows.Next() {
 		var c Comment
 		var u User
 		var avatar sql.NullString
-		dest := []interface{}{&c.ID, &c.Content, &c.LikesCount, &c.CreatedAt, &u.Username, &avatar}
+		dest := []interface{}{&c.ID, &c.Content, &c.LikesCount, &c.RepliesCount, &c.CreatedAt, &u.Username, &avatar}
 		if auth {
 			dest = append(dest, &c.Mine, &c.Liked)
 		}
 		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan comment: %w", err)
+			return out, fmt.Errorf("could not scan comment: %w", err)
 		}
 
-		u.AvatarURL = s.avatarURL(avatar)
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
 		c.User = &u
 		cc = append(cc, c)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate comment rows: %w", err)
+		return out, fmt.Errorf("could not iterate comment rows: %w", err)
 	}
 
-	return cc, nil
+	if len(cc) > last {
+		cc = cc[:last]
+		out.PageInfo = nextPage(timeCursor{Time: cc[last-1].CreatedAt, ID: cc[last-1].ID})
+	}
+
+	out.Items = cc
+	return out, nil
+}
+
+// CommentReplies to a comment in descending order with backward pagination.
+func (s *Service) CommentReplies(ctx context.Context, commentID string, last int, before string) (CommentsOutput, error) {
+	var out CommentsOutput
+	if !reUUID.MatchString(commentID) {
+		return out, ErrInvalidCommentID
+	}
+
+	var cursor timeCursor
+	if before != "" {
+		var err error
+		if cursor, err = decodeTimeCursor(before); err != nil {
+			return out, err
+		}
+	}
+
+	hidden, err := s.hiddenAccount(ctx, ownerByComment, commentID)
+	if err != nil {
+		return out, err
+	}
+
+	if hidden {
+		return out, ErrPrivateAccount
+	}
+
+	hidden, err = s.hiddenPost(ctx, postByComment, commentID)
+	if err != nil {
+		return out, err
+	}
+
+	if hidden {
+		return out, ErrPostNotFound
+	}
+
+	uid, auth := ctx.Value(KeyAuthUserID).(string)
+	last = normalizePageSize(last)
+	query, args, err := buildQuery(`
+		SELECT comments.id, content, likes_count, replies_count, comments.created_at, username, avatar
+		{{if .auth}}
+		, comments.user_id = @uid AS mine
+		, likes.user_id IS NOT NULL AS liked
+		{{end}}
+		FROM comments
+		INNER JOIN users ON comments.user_id = users.id
+		{{if .auth}}
+		LEFT JOIN comment_likes AS likes
+			ON likes.comment_id = comments.id AND likes.user_id = @uid
+		{{end}}
+		WHERE comments.parent_id = @comment_id
+		{{if .before}}AND (comments.created_at, comments.id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+		ORDER BY comments.created_at DESC, comments.id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"auth":       auth,
+		"uid":        uid,
+		"comment_id": commentID,
+		"before":     before != "",
+		"cursorTime": cursor.Time,
+		"cursorID":   cursor.ID,
+		"limit":      last + 1,
+	})
+	if err != nil {
+		return out, fmt.Errorf("could not build comment replies sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return out, fmt.Errorf("could not query select comment replies: %w", err)
+	}
+
+	defer rows.Close()
+
+	cc := make([]Comment, 0, last+1)
+	for rows.Next() {
+		var c Comment
+		var u User
+		var avatar sql.NullString
+		dest := []interface{}{&c.ID, &c.Content, &c.LikesCount, &c.RepliesCount, &c.CreatedAt, &u.Username, &avatar}
+		if auth {
+			dest = append(dest, &c.Mine, &c.Liked)
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return out, fmt.Errorf("could not scan comment reply: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		c.User = &u
+		c.ParentID = &commentID
+		cc = append(cc, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return out, fmt.Errorf("could not iterate comment reply rows: %w", err)
+	}
+
+	if len(cc) > last {
+		cc = cc[:last]
+		out.PageInfo = nextPage(timeCursor{Time: cc[last-1].CreatedAt, ID: cc[last-1].ID})
+	}
+
+	out.Items = cc
+	return out, nil
 }
 
 // CommentStream to receive comments in realtime.
@@ -184,6 +414,24 @@ func (s *Service) CommentStream(ctx context.Context, postID string) (<-chan Comm
 		return nil, ErrInvalidPostID
 	}
 
+	hidden, err := s.hiddenAccount(ctx, ownerByPost, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if hidden {
+		return nil, ErrPrivateAccount
+	}
+
+	hidden, err = s.hiddenPost(ctx, postByID, postID)
+	if err != nil {
+		return nil, err
+	}
+
+	if hidden {
+		return nil, ErrPostNotFound
+	}
+
 	cc := make(chan Comment)
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	unsub, err := s.pubsub.Sub(commentTopic(postID), func(data []byte) {
@@ -218,6 +466,186 @@ func (s *Service) CommentStream(ctx context.Context, postID string) (<-chan Comm
 	return cc, nil
 }
 
+// UpdateComment changes the content of a comment owned by the authenticated user.
+func (s *Service) UpdateComment(ctx context.Context, commentID string, content string) (Comment, error) {
+	var c Comment
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return c, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(commentID) {
+		return c, ErrInvalidCommentID
+	}
+
+	content = smartTrim(content)
+	if content == "" || utf8.RuneCountInString(content) > 480 {
+		return c, ErrInvalidContent
+	}
+
+	var oldContent string
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		var userID string
+		query := "SELECT user_id, post_id, content FROM comments WHERE id = $1 FOR UPDATE"
+		err := tx.QueryRowContext(ctx, query, commentID).Scan(&userID, &c.PostID, &oldContent)
+		if err == sql.ErrNoRows {
+			return ErrCommentNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select comment to update: %w", err)
+		}
+
+		if userID != uid {
+			return ErrPermissionDenied
+		}
+
+		query = `
+			UPDATE comments SET content = $1 WHERE id = $2
+			RETURNING parent_id, likes_count, replies_count, created_at`
+		err = tx.QueryRowContext(ctx, query, content, commentID).
+			Scan(&c.ParentID, &c.LikesCount, &c.RepliesCount, &c.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("could not update comment: %w", err)
+		}
+
+		if err = updateTags(ctx, tx, c.PostID, &commentID, oldContent, content); err != nil {
+			return err
+		}
+
+		query = `
+			SELECT EXISTS (
+				SELECT 1 FROM comment_likes WHERE user_id = $1 AND comment_id = $2
+			)`
+		if err = tx.QueryRowContext(ctx, query, uid, commentID).Scan(&c.Liked); err != nil {
+			return fmt.Errorf("could not query select comment like existence: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return c, err
+	}
+
+	c.ID = commentID
+	c.UserID = uid
+	c.Content = content
+	c.Mine = true
+
+	if content != oldContent {
+		go s.commentUpdated(c, addedMentions(oldContent, content))
+	}
+
+	return c, nil
+}
+
+func (s *Service) commentUpdated(c Comment, mentions []string) {
+	u, err := s.userByID(context.Background(), c.UserID)
+	if err != nil {
+		log.Printf("could not fetch comment user: %v\n", err)
+		return
+	}
+
+	c.User = &u
+	c.Mine = false
+	c.Liked = false
+
+	go s.notifyCommentMention(c, mentions)
+	go s.broadcastComment(c)
+}
+
+// DeleteComment owned by the authenticated user along with its likes and replies.
+func (s *Service) DeleteComment(ctx context.Context, commentID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(commentID) {
+		return ErrInvalidCommentID
+	}
+
+	var postID string
+	var parentID *string
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		var userID string
+		var repliesCount int
+		query := "SELECT user_id, post_id, parent_id, replies_count FROM comments WHERE id = $1 FOR UPDATE"
+		err := tx.QueryRowContext(ctx, query, commentID).Scan(&userID, &postID, &parentID, &repliesCount)
+		if err == sql.ErrNoRows {
+			return ErrCommentNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select comment to delete: %w", err)
+		}
+
+		if userID != uid {
+			return ErrPermissionDenied
+		}
+
+		// Replies go away together with the comment they belong to.
+		query = `
+			DELETE FROM comment_likes
+			WHERE comment_id = $1
+				OR comment_id IN (SELECT id FROM comments WHERE parent_id = $1)`
+		if _, err = tx.ExecContext(ctx, query, commentID); err != nil {
+			return fmt.Errorf("could not delete comment likes: %w", err)
+		}
+
+		query = `
+			DELETE FROM tags
+			WHERE comment_id = $1
+				OR comment_id IN (SELECT id FROM comments WHERE parent_id = $1)`
+		if _, err = tx.ExecContext(ctx, query, commentID); err != nil {
+			return fmt.Errorf("could not delete comment tags: %w", err)
+		}
+
+		query = "DELETE FROM comments WHERE parent_id = $1"
+		res, err := tx.ExecContext(ctx, query, commentID)
+		if err != nil {
+			return fmt.Errorf("could not delete comment replies: %w", err)
+		}
+
+		deletedReplies, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("could not get deleted comment replies count: %w", err)
+		}
+
+		query = "DELETE FROM comments WHERE id = $1"
+		if _, err = tx.ExecContext(ctx, query, commentID); err != nil {
+			return fmt.Errorf("could not delete comment: %w", err)
+		}
+
+		if parentID != nil {
+			query = "UPDATE comments SET replies_count = replies_count - 1 WHERE id = $1"
+			if _, err = tx.ExecContext(ctx, query, *parentID); err != nil {
+				return fmt.Errorf("could not update and decrement comment replies count: %w", err)
+			}
+		}
+
+		query = "UPDATE posts SET comments_count = comments_count - $1 WHERE id = $2"
+		if _, err = tx.ExecContext(ctx, query, 1+deletedReplies, postID); err != nil {
+			return fmt.Errorf("could not update and decrement post comments count: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	go s.broadcastComment(Comment{
+		ID:       commentID,
+		UserID:   uid,
+		PostID:   postID,
+		ParentID: parentID,
+		Deleted:  true,
+	})
+
+	return nil
+}
+
 // ToggleCommentLike 🖤
 func (s *Service) ToggleCommentLike(ctx context.Context, commentID string) (ToggleLikeOutput, error) {
 	var out ToggleLikeOutput
@@ -0,0 +1,705 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+	"github.com/lib/pq"
+)
+
+const (
+	// maxConversationMembers is the max number of users in a conversation,
+	// the authenticated user included.
+	maxConversationMembers = 10
+	// maxMessageLength is the max number of characters of a message.
+	maxMessageLength = 1000
+)
+
+var (
+	// ErrInvalidConversationID denotes an invalid conversation id; that is not uuid.
+	ErrInvalidConversationID = errors.New("invalid conversation id")
+	// ErrInvalidConversationMembers denotes a conversation without anyone else
+	// or with too many members.
+	ErrInvalidConversationMembers = errors.New("invalid conversation members")
+	// ErrConversationNotFound denotes a not found conversation
+	// or one the authenticated user is not member of.
+	ErrConversationNotFound = errors.New("conversation not found")
+)
+
+// Conversation model.
+// One-to-one conversations are unique per pair of users; group ones are not.
+type Conversation struct {
+	ID          string    `json:"id"`
+	Members     []User    `json:"members"` // everyone but the authenticated user
+	Group       bool      `json:"group"`
+	LastMessage *Message  `json:"lastMessage,omitempty"`
+	UnreadCount int       `json:"unreadCount"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// ConversationsOutput response.
+type ConversationsOutput struct {
+	Items []Conversation `json:"items"`
+	PageInfo
+}
+
+// Message model.
+type Message struct {
+	ID             string    `json:"id"`
+	ConversationID string    `json:"conversationID"`
+	UserID         string    `json:"-"`
+	Content        string    `json:"content"`
+	User           *User     `json:"user,omitempty"`
+	Mine           bool      `json:"mine"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
+
+// MessagesOutput response.
+type MessagesOutput struct {
+	Items []Message `json:"items"`
+	PageInfo
+}
+
+// CreateConversation between the authenticated user and the given users.
+// With a single user it returns the existing one-to-one conversation if any.
+// Only blocks involving the authenticated user prevent it; group members
+// blocking each other can share a group but don't get each other's messages.
+func (s *Service) CreateConversation(ctx context.Context, usernames []string) (Conversation, error) {
+	var c Conversation
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return c, ErrUnauthenticated
+	}
+
+	seen := map[string]struct{}{}
+	unique := []string{}
+	for _, username := range usernames {
+		username = strings.TrimSpace(username)
+		if !reUsername.MatchString(username) {
+			return c, ErrInvalidUsername
+		}
+
+		if _, ok := seen[username]; ok {
+			continue
+		}
+
+		seen[username] = struct{}{}
+		unique = append(unique, username)
+	}
+
+	if len(unique) == 0 || len(unique) >= maxConversationMembers {
+		return c, ErrInvalidConversationMembers
+	}
+
+	var conversationID string
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		query := "SELECT id FROM users WHERE username = ANY($1)"
+		rows, err := tx.QueryContext(ctx, query, pq.Array(unique))
+		if err != nil {
+			return fmt.Errorf("could not query select conversation members: %w", err)
+		}
+
+		var found int
+		memberIDs := []string{}
+		for rows.Next() {
+			var id string
+			if err = rows.Scan(&id); err != nil {
+				rows.Close()
+				return fmt.Errorf("could not scan conversation member: %w", err)
+			}
+
+			found++
+			if id != uid {
+				memberIDs = append(memberIDs, id)
+			}
+		}
+		rows.Close()
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("could not iterate conversation member rows: %w", err)
+		}
+
+		if found != len(unique) {
+			return ErrUserNotFound
+		}
+
+		if len(memberIDs) == 0 {
+			return ErrInvalidConversationMembers
+		}
+
+		for _, memberID := range memberIDs {
+			blocked, err := blockedBetween(ctx, tx, uid, memberID)
+			if err != nil {
+				return err
+			}
+
+			if blocked {
+				return ErrUserBlocked
+			}
+		}
+
+		// One-to-one conversations get a key out of both user ids so they don't get duplicated.
+		var directKey *string
+		if len(memberIDs) == 1 {
+			ids := []string{uid, memberIDs[0]}
+			sort.Strings(ids)
+			key := strings.Join(ids, ":")
+			directKey = &key
+		}
+
+		query = `
+			INSERT INTO conversations (direct_key) VALUES ($1)
+			ON CONFLICT (direct_key) DO UPDATE SET direct_key = excluded.direct_key
+			RETURNING id`
+		if err = tx.QueryRowContext(ctx, query, directKey).Scan(&conversationID); err != nil {
+			return fmt.Errorf("could not insert conversation: %w", err)
+		}
+
+		query = `
+			INSERT INTO conversation_members (conversation_id, user_id)
+			SELECT $1, unnest($2::UUID[])
+			ON CONFLICT (conversation_id, user_id) DO NOTHING`
+		if _, err = tx.ExecContext(ctx, query, conversationID, pq.Array(append(memberIDs, uid))); err != nil {
+			return fmt.Errorf("could not insert conversation members: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return c, err
+	}
+
+	return s.Conversation(ctx, conversationID)
+}
+
+// Conversations of the authenticated user in descending order with backward pagination.
+// The ones with the latest messages come first.
+func (s *Service) Conversations(ctx context.Context, last int, before string) (ConversationsOutput, error) {
+	var out ConversationsOutput
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	var cursor *timeCursor
+	if before != "" {
+		c, err := decodeTimeCursor(before)
+		if err != nil {
+			return out, err
+		}
+
+		cursor = &c
+	}
+
+	last = normalizePageSize(last)
+	cc, err := s.conversations(ctx, uid, "", last+1, cursor)
+	if err != nil {
+		return out, err
+	}
+
+	if len(cc) > last {
+		cc = cc[:last]
+		out.PageInfo = nextPage(timeCursor{Time: cc[last-1].UpdatedAt, ID: cc[last-1].ID})
+	}
+
+	out.Items = cc
+	return out, nil
+}
+
+// Conversation with the given ID. Only its members can access it.
+func (s *Service) Conversation(ctx context.Context, conversationID string) (Conversation, error) {
+	var c Conversation
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return c, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return c, ErrInvalidConversationID
+	}
+
+	cc, err := s.conversations(ctx, uid, conversationID, 1, nil)
+	if err != nil {
+		return c, err
+	}
+
+	if len(cc) == 0 {
+		return c, ErrConversationNotFound
+	}
+
+	return cc[0], nil
+}
+
+func (s *Service) conversations(ctx context.Context, uid, conversationID string, limit int, cursor *timeCursor) ([]Conversation, error) {
+	data := map[string]interface{}{
+		"uid":            uid,
+		"conversationID": conversationID,
+		"limit":          limit,
+	}
+	if cursor != nil {
+		data["before"] = true
+		data["cursorTime"] = cursor.Time
+		data["cursorID"] = cursor.ID
+	}
+
+	query, args, err := buildQuery(`
+		SELECT conversations.id, conversations.direct_key IS NULL AS is_group, conversations.updated_at
+		, members.unread_count
+		, messages.id, messages.content, messages.created_at, messages.user_id = @uid AS mine
+		, senders.username, senders.avatar
+		FROM conversation_members AS members
+		INNER JOIN conversations ON members.conversation_id = conversations.id
+		LEFT JOIN messages ON conversations.last_message_id = messages.id
+		LEFT JOIN users AS senders ON messages.user_id = senders.id
+		WHERE members.user_id = @uid
+		{{if .conversationID}}AND conversations.id = @conversationID{{end}}
+		{{if .before}}AND (conversations.updated_at, conversations.id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+		ORDER BY conversations.updated_at DESC, conversations.id DESC
+		LIMIT @limit`, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not build conversations sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select conversations: %w", err)
+	}
+
+	defer rows.Close()
+
+	cc := make([]Conversation, 0, limit)
+	for rows.Next() {
+		var c Conversation
+		var messageID, messageContent *string
+		var messageCreatedAt *time.Time
+		var mine sql.NullBool
+		var senderUsername, senderAvatar sql.NullString
+		if err = rows.Scan(
+			&c.ID,
+			&c.Group,
+			&c.UpdatedAt,
+			&c.UnreadCount,
+			&messageID,
+			&messageContent,
+			&messageCreatedAt,
+			&mine,
+			&senderUsername,
+			&senderAvatar,
+		); err != nil {
+			return nil, fmt.Errorf("could not scan conversation: %w", err)
+		}
+
+		if messageID != nil {
+			c.LastMessage = &Message{
+				ID:             *messageID,
+				ConversationID: c.ID,
+				Content:        *messageContent,
+				Mine:           mine.Bool,
+				CreatedAt:      *messageCreatedAt,
+				User: &User{
+					Username:   senderUsername.String,
+					AvatarURL:  s.avatarURL(senderUsername.String, senderAvatar),
+					AvatarURLs: s.avatarURLs(senderUsername.String, senderAvatar),
+				},
+			}
+		}
+
+		cc = append(cc, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate conversation rows: %w", err)
+	}
+
+	if err = s.attachConversationMembers(ctx, uid, cc); err != nil {
+		return nil, err
+	}
+
+	return cc, nil
+}
+
+// attachConversationMembers but the given user, in ascending order by username.
+func (s *Service) attachConversationMembers(ctx context.Context, uid string, cc []Conversation) error {
+	if len(cc) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(cc))
+	byID := make(map[string]*Conversation, len(cc))
+	for i := range cc {
+		ids[i] = cc[i].ID
+		byID[cc[i].ID] = &cc[i]
+		cc[i].Members = []User{}
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT conversation_members.conversation_id, users.username, users.avatar
+		FROM conversation_members
+		INNER JOIN users ON conversation_members.user_id = users.id
+		WHERE conversation_members.conversation_id = ANY($1)
+			AND conversation_members.user_id != $2
+		ORDER BY users.username ASC`, pq.Array(ids), uid)
+	if err != nil {
+		return fmt.Errorf("could not query select conversation members: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var conversationID string
+		var u User
+		var avatar sql.NullString
+		if err = rows.Scan(&conversationID, &u.Username, &avatar); err != nil {
+			return fmt.Errorf("could not scan conversation member: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		c := byID[conversationID]
+		c.Members = append(c.Members, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate conversation member rows: %w", err)
+	}
+
+	return nil
+}
+
+// Messages from a conversation in descending order with backward pagination.
+// Messages from users with a block between them and the authenticated one are left out.
+func (s *Service) Messages(ctx context.Context, conversationID string, last int, before string) (MessagesOutput, error) {
+	var out MessagesOutput
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return out, ErrInvalidConversationID
+	}
+
+	var cursor timeCursor
+	if before != "" {
+		var err error
+		if cursor, err = decodeTimeCursor(before); err != nil {
+			return out, err
+		}
+	}
+
+	member, err := conversationMember(ctx, s.db, conversationID, uid)
+	if err != nil {
+		return out, err
+	}
+
+	if !member {
+		return out, ErrConversationNotFound
+	}
+
+	last = normalizePageSize(last)
+	query, args, err := buildQuery(`
+		SELECT messages.id, messages.content, messages.created_at, messages.user_id = @uid AS mine
+		, users.username, users.avatar
+		FROM messages
+		INNER JOIN users ON messages.user_id = users.id
+		WHERE messages.conversation_id = @conversationID
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = messages.user_id AND blocked_id = @uid)
+				OR (blocker_id = @uid AND blocked_id = messages.user_id)
+		)
+		{{if .before}}AND (messages.created_at, messages.id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+		ORDER BY messages.created_at DESC, messages.id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"uid":            uid,
+		"conversationID": conversationID,
+		"before":         before != "",
+		"cursorTime":     cursor.Time,
+		"cursorID":       cursor.ID,
+		"limit":          last + 1,
+	})
+	if err != nil {
+		return out, fmt.Errorf("could not build messages sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return out, fmt.Errorf("could not query select messages: %w", err)
+	}
+
+	defer rows.Close()
+
+	mm := make([]Message, 0, last+1)
+	for rows.Next() {
+		var m Message
+		var u User
+		var avatar sql.NullString
+		if err = rows.Scan(&m.ID, &m.Content, &m.CreatedAt, &m.Mine, &u.Username, &avatar); err != nil {
+			return out, fmt.Errorf("could not scan message: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		m.ConversationID = conversationID
+		m.User = &u
+		mm = append(mm, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return out, fmt.Errorf("could not iterate message rows: %w", err)
+	}
+
+	if len(mm) > last {
+		mm = mm[:last]
+		out.PageInfo = nextPage(timeCursor{Time: mm[last-1].CreatedAt, ID: mm[last-1].ID})
+	}
+
+	out.Items = mm
+	return out, nil
+}
+
+// SendMessage to a conversation of the authenticated user.
+// The rest of members get it in realtime and their unread count incremented,
+// except those with a block between them and the sender.
+func (s *Service) SendMessage(ctx context.Context, conversationID, content string) (Message, error) {
+	var m Message
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return m, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return m, ErrInvalidConversationID
+	}
+
+	content = smartTrim(content)
+	if content == "" || utf8.RuneCountInString(content) > maxMessageLength {
+		return m, ErrInvalidContent
+	}
+
+	var recipients []string
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		var direct bool
+		query := `
+			SELECT conversations.direct_key IS NOT NULL FROM conversations
+			INNER JOIN conversation_members AS members
+				ON members.conversation_id = conversations.id AND members.user_id = $2
+			WHERE conversations.id = $1`
+		err := tx.QueryRowContext(ctx, query, conversationID, uid).Scan(&direct)
+		if err == sql.ErrNoRows {
+			return ErrConversationNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select conversation: %w", err)
+		}
+
+		if direct {
+			var otherID string
+			query = "SELECT user_id FROM conversation_members WHERE conversation_id = $1 AND user_id != $2"
+			err = tx.QueryRowContext(ctx, query, conversationID, uid).Scan(&otherID)
+			if err != nil && err != sql.ErrNoRows {
+				return fmt.Errorf("could not query select conversation recipient: %w", err)
+			}
+
+			if err == nil {
+				blocked, err := blockedBetween(ctx, tx, uid, otherID)
+				if err != nil {
+					return err
+				}
+
+				if blocked {
+					return ErrUserBlocked
+				}
+			}
+		}
+
+		query = `
+			INSERT INTO messages (conversation_id, user_id, content) VALUES ($1, $2, $3)
+			RETURNING id, created_at`
+		err = tx.QueryRowContext(ctx, query, conversationID, uid, content).Scan(&m.ID, &m.CreatedAt)
+		if err != nil {
+			return fmt.Errorf("could not insert message: %w", err)
+		}
+
+		query = "UPDATE conversations SET last_message_id = $1, updated_at = $2 WHERE id = $3"
+		if _, err = tx.ExecContext(ctx, query, m.ID, m.CreatedAt, conversationID); err != nil {
+			return fmt.Errorf("could not update conversation last message: %w", err)
+		}
+
+		query = `
+			UPDATE conversation_members SET unread_count = unread_count + 1
+			WHERE conversation_id = $1 AND user_id != $2
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks
+				WHERE (blocker_id = $2 AND blocked_id = conversation_members.user_id)
+					OR (blocker_id = conversation_members.user_id AND blocked_id = $2)
+			)
+			RETURNING user_id`
+		rows, err := tx.QueryContext(ctx, query, conversationID, uid)
+		if err != nil {
+			return fmt.Errorf("could not update and increment conversation unread count: %w", err)
+		}
+
+		defer rows.Close()
+
+		recipients = nil
+		for rows.Next() {
+			var recipient string
+			if err = rows.Scan(&recipient); err != nil {
+				return fmt.Errorf("could not scan message recipient: %w", err)
+			}
+
+			recipients = append(recipients, recipient)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("could not iterate message recipient rows: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return m, err
+	}
+
+	m.ConversationID = conversationID
+	m.UserID = uid
+	m.Content = content
+	m.Mine = true
+
+	go s.messageCreated(m, recipients)
+
+	return m, nil
+}
+
+func (s *Service) messageCreated(m Message, recipients []string) {
+	u, err := s.userByID(context.Background(), m.UserID)
+	if err != nil {
+		log.Printf("could not fetch message user: %v\n", err)
+		return
+	}
+
+	u.ID = ""
+	m.User = &u
+	m.Mine = false
+
+	for _, recipient := range recipients {
+		go s.broadcastMessage(m, recipient)
+	}
+}
+
+// MessageStream to receive the messages sent to the authenticated user in realtime.
+func (s *Service) MessageStream(ctx context.Context) (<-chan Message, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	mm := make(chan Message)
+	unsub, err := s.pubsub.Sub(messageTopic(uid), func(data []byte) {
+		go func(r io.Reader) {
+			var m Message
+			err := gob.NewDecoder(r).Decode(&m)
+			if err != nil {
+				log.Printf("could not gob decode message: %v\n", err)
+				return
+			}
+
+			mm <- m
+		}(bytes.NewReader(data))
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not subcribe to messages: %w", err)
+	}
+
+	go func() {
+		<-ctx.Done()
+		if err := unsub(); err != nil {
+			log.Printf("could not unsubcribe from messages: %v\n", err)
+			// don't return
+		}
+		close(mm)
+	}()
+
+	return mm, nil
+}
+
+// HasUnreadMessages checks if the authenticated user has any unread message.
+func (s *Service) HasUnreadMessages(ctx context.Context) (bool, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return false, ErrUnauthenticated
+	}
+
+	var unread bool
+	if err := s.db.QueryRowContext(ctx, `SELECT EXISTS (
+		SELECT 1 FROM conversation_members WHERE user_id = $1 AND unread_count > 0
+	)`, uid).Scan(&unread); err != nil {
+		return false, fmt.Errorf("could not query select unread messages existence: %w", err)
+	}
+
+	return unread, nil
+}
+
+// MarkConversationAsRead resets the unread count of a conversation from the authenticated user.
+func (s *Service) MarkConversationAsRead(ctx context.Context, conversationID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(conversationID) {
+		return ErrInvalidConversationID
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE conversation_members SET unread_count = 0
+		WHERE conversation_id = $1 AND user_id = $2 AND unread_count > 0`, conversationID, uid); err != nil {
+		return fmt.Errorf("could not update and mark conversation as read: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) broadcastMessage(m Message, recipient string) {
+	var b bytes.Buffer
+	err := gob.NewEncoder(&b).Encode(m)
+	if err != nil {
+		log.Printf("could not gob encode message: %v\n", err)
+		return
+	}
+
+	err = s.pubsub.Pub(messageTopic(recipient), b.Bytes())
+	if err != nil {
+		log.Printf("could not publish message: %v\n", err)
+		return
+	}
+}
+
+// conversationMember reports whether the given user is member of the conversation.
+func conversationMember(ctx context.Context, db queryRower, conversationID, userID string) (bool, error) {
+	var member bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM conversation_members WHERE conversation_id = $1 AND user_id = $2
+		)`
+	if err := db.QueryRowContext(ctx, query, conversationID, userID).Scan(&member); err != nil {
+		return false, fmt.Errorf("could not query select conversation membership: %w", err)
+	}
+
+	return member, nil
+}
+
+func messageTopic(userID string) string { return "message_" + userID }
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"html/template"
+	"log"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+var (
+	emailVerificationMailTmpl *template.Template
+	emailChangeNoticeMailTmpl *template.Template
+)
+
+// UpdateEmail of the authenticated user.
+// A confirmation link is sent to the new address and users.email only changes after it gets clicked.
+// The current address is notified too, so the owner can spot a hijack attempt.
+func (s *Service) UpdateEmail(ctx context.Context, email, redirectURI string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	email = strings.TrimSpace(email)
+	if !reEmail.MatchString(email) {
+		return ErrInvalidEmail
+	}
+
+	uri, err := url.ParseRequestURI(redirectURI)
+	if err != nil {
+		return ErrInvalidRedirectURI
+	}
+
+	var oldEmail, username string
+	var taken bool
+	err = s.db.QueryRowContext(ctx, `
+		SELECT email, username, EXISTS (SELECT 1 FROM users WHERE email = $2)
+		FROM users WHERE id = $1`, uid, email).Scan(&oldEmail, &username, &taken)
+	if err == sql.ErrNoRows {
+		return ErrUserNotFound
+	}
+
+	if err != nil {
+		return fmt.Errorf("could not query select user email: %w", err)
+	}
+
+	if taken {
+		return ErrEmailTaken
+	}
+
+	var code string
+	query := "INSERT INTO verification_codes (user_id, email) VALUES ($1, $2) RETURNING id"
+	if err = s.db.QueryRowContext(ctx, query, uid, email).Scan(&code); err != nil {
+		return fmt.Errorf("could not insert email verification code: %w", err)
+	}
+
+	defer func() {
+		if err != nil {
+			go func() {
+				_, err := s.db.Exec("DELETE FROM verification_codes WHERE id = $1", code)
+				if err != nil {
+					log.Printf("could not delete email verification code: %v\n", err)
+				}
+			}()
+		}
+	}()
+
+	if emailChangeNoticeMailTmpl == nil {
+		emailChangeNoticeMailTmpl, err = template.ParseFiles(filepath.Join(s.templateDir, "/mail/email-change-notice.html"))
+		if err != nil {
+			return fmt.Errorf("could not parse email change notice mail template: %w", err)
+		}
+	}
+
+	var b bytes.Buffer
+	if err = emailChangeNoticeMailTmpl.Execute(&b, map[string]interface{}{
+		"Username": username,
+		"Email":    email,
+	}); err != nil {
+		return fmt.Errorf("could not execute email change notice mail template: %w", err)
+	}
+
+	if err = s.sender.Send(oldEmail, "Email change requested", b.String()); err != nil {
+		return fmt.Errorf("could not send email change notice: %w", err)
+	}
+
+	link := cloneURL(s.origin)
+	link.Path = "/api/verify_email"
+	q := link.Query()
+	q.Set("verification_code", code)
+	q.Set("redirect_uri", uri.String())
+	link.RawQuery = q.Encode()
+
+	if emailVerificationMailTmpl == nil {
+		emailVerificationMailTmpl, err = template.ParseFiles(filepath.Join(s.templateDir, "/mail/email-verification.html"))
+		if err != nil {
+			return fmt.Errorf("could not parse email verification mail template: %w", err)
+		}
+	}
+
+	b.Reset()
+	if err = emailVerificationMailTmpl.Execute(&b, map[string]interface{}{
+		"Username":         username,
+		"VerificationLink": link.String(),
+		"Minutes":          int(verificationCodeLifespan.Minutes()),
+	}); err != nil {
+		return fmt.Errorf("could not execute email verification mail template: %w", err)
+	}
+
+	if err = s.sender.Send(email, "Verify your email", b.String()); err != nil {
+		return fmt.Errorf("could not send email verification: %w", err)
+	}
+
+	return nil
+}
+
+// VerifyEmail swaps the user email with the one the verification code was sent to.
+// It returns the URI to be redirected to.
+func (s *Service) VerifyEmail(ctx context.Context, verificationCode, redirectURI string) (string, error) {
+	verificationCode = strings.TrimSpace(verificationCode)
+	if !reUUID.MatchString(verificationCode) {
+		return "", ErrInvalidVerificationCode
+	}
+
+	uri, err := url.ParseRequestURI(redirectURI)
+	if err != nil {
+		return "", ErrInvalidRedirectURI
+	}
+
+	err = crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		var uid, email string
+		var createdAt time.Time
+		err := tx.QueryRowContext(ctx, `
+			DELETE FROM verification_codes WHERE id = $1 AND email IS NOT NULL
+			RETURNING user_id, email, created_at`, verificationCode).Scan(&uid, &email, &createdAt)
+		if err == sql.ErrNoRows {
+			return ErrVerificationCodeNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not delete email verification code: %w", err)
+		}
+
+		now := time.Now()
+		exp := createdAt.Add(verificationCodeLifespan)
+		if exp.Equal(now) || exp.Before(now) {
+			return ErrExpiredToken
+		}
+
+		_, err = tx.ExecContext(ctx, "UPDATE users SET email = $1 WHERE id = $2", email, uid)
+		if isUniqueViolation(err) {
+			return ErrEmailTaken
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not update user email: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return uri.String(), nil
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+var (
+	// ErrPrivateAccount denotes an access to the content of a private account
+	// by someone that is not an approved follower.
+	ErrPrivateAccount = errors.New("private account")
+	// ErrFollowRequestNotFound denotes a not found follow request.
+	ErrFollowRequestNotFound = errors.New("follow request not found")
+)
+
+// Queries for hiddenAccount selecting the account owner out of @id.
+const (
+	ownerByUsername = "SELECT id FROM users WHERE username = @id"
+	ownerByPost     = "SELECT user_id FROM posts WHERE id = @id"
+	ownerByComment  = "SELECT posts.user_id FROM comments INNER JOIN posts ON comments.post_id = posts.id WHERE comments.id = @id"
+)
+
+// FollowRequests to the authenticated user in ascending order with forward pagination.
+func (s *Service) FollowRequests(ctx context.Context, first int, after string) ([]UserProfile, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	first = normalizePageSize(first)
+	after = strings.TrimSpace(after)
+	query, args, err := buildQuery(`
+		SELECT username, avatar, followers_count, followees_count
+		, display_name, bio, location, website
+		, followees.followee_id IS NOT NULL AS followeed
+		FROM follow_requests
+		INNER JOIN users ON follow_requests.follower_id = users.id
+		LEFT JOIN follows AS followees
+			ON followees.follower_id = users.id AND followees.followee_id = @uid
+		WHERE follow_requests.followee_id = @uid
+		{{if .after}}AND username > @after{{end}}
+		ORDER BY username ASC
+		LIMIT @first`, map[string]interface{}{
+		"uid":   uid,
+		"first": first,
+		"after": after,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not build follow requests sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select follow requests: %w", err)
+	}
+
+	defer rows.Close()
+	uu := make([]UserProfile, 0, first)
+	for rows.Next() {
+		var u UserProfile
+		var avatar sql.NullString
+		dest := []interface{}{
+			&u.Username,
+			&avatar,
+			&u.FollowersCount,
+			&u.FolloweesCount,
+			&u.DisplayName,
+			&u.Bio,
+			&u.Location,
+			&u.Website,
+			&u.Followeed,
+		}
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan follow request: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		uu = append(uu, u)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate follow request rows: %w", err)
+	}
+
+	return uu, nil
+}
+
+// AcceptFollowRequest from the given user to the authenticated user.
+// The requester becomes a follower.
+func (s *Service) AcceptFollowRequest(ctx context.Context, username string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	username = strings.TrimSpace(username)
+	if !reUsername.MatchString(username) {
+		return ErrInvalidUsername
+	}
+
+	var followerID string
+	var inserted bool
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		query := `
+			DELETE FROM follow_requests
+			WHERE follower_id = (SELECT id FROM users WHERE username = $1) AND followee_id = $2
+			RETURNING follower_id`
+		err := tx.QueryRowContext(ctx, query, username, uid).Scan(&followerID)
+		if err == sql.ErrNoRows {
+			return ErrFollowRequestNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not delete follow request: %w", err)
+		}
+
+		query = "INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2) ON CONFLICT DO NOTHING"
+		result, err := tx.ExecContext(ctx, query, followerID, uid)
+		if err != nil {
+			return fmt.Errorf("could not insert follow: %w", err)
+		}
+
+		n, _ := result.RowsAffected()
+		inserted = n != 0
+		if !inserted {
+			return nil
+		}
+
+		query = "UPDATE users SET followees_count = followees_count + 1 WHERE id = $1"
+		if _, err = tx.ExecContext(ctx, query, followerID); err != nil {
+			return fmt.Errorf("could not increment followees count: %w", err)
+		}
+
+		query = "UPDATE users SET followers_count = followers_count + 1 WHERE id = $1"
+		if _, err = tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not increment followers count: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if inserted {
+		go s.notifyFollow(followerID, uid)
+	}
+
+	return nil
+}
+
+// RejectFollowRequest from the given user to the authenticated user.
+func (s *Service) RejectFollowRequest(ctx context.Context, username string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	username = strings.TrimSpace(username)
+	if !reUsername.MatchString(username) {
+		return ErrInvalidUsername
+	}
+
+	query := `
+		DELETE FROM follow_requests
+		WHERE follower_id = (SELECT id FROM users WHERE username = $1) AND followee_id = $2`
+	result, err := s.db.ExecContext(ctx, query, username, uid)
+	if err != nil {
+		return fmt.Errorf("could not delete follow request: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrFollowRequestNotFound
+	}
+
+	return nil
+}
+
+// toggleFollowRequest creates a follow request or cancels the pending one.
+// It reports whether there is a pending request after the toggle.
+func toggleFollowRequest(ctx context.Context, tx *sql.Tx, followerID, followeeID string) (bool, error) {
+	query := "DELETE FROM follow_requests WHERE follower_id = $1 AND followee_id = $2"
+	result, err := tx.ExecContext(ctx, query, followerID, followeeID)
+	if err != nil {
+		return false, fmt.Errorf("could not delete follow request: %w", err)
+	}
+
+	if n, _ := result.RowsAffected(); n != 0 {
+		return false, nil
+	}
+
+	query = "INSERT INTO follow_requests (follower_id, followee_id) VALUES ($1, $2)"
+	if _, err = tx.ExecContext(ctx, query, followerID, followeeID); err != nil {
+		return false, fmt.Errorf("could not insert follow request: %w", err)
+	}
+
+	return true, nil
+}
+
+// hiddenAccount reports whether the account of the user selected by ownerQuery
+// is private and the authenticated user is neither its owner nor an approved follower.
+// Unknown users are not hidden.
+func (s *Service) hiddenAccount(ctx context.Context, ownerQuery, id string) (bool, error) {
+	uid, auth := ctx.Value(KeyAuthUserID).(string)
+	query, args, err := buildQuery(`
+		SELECT private
+		{{if .auth}}
+		AND id != @uid
+		AND NOT EXISTS (
+			SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = users.id
+		)
+		{{end}}
+		FROM users WHERE id = (`+ownerQuery+`)`, map[string]interface{}{
+		"auth": auth,
+		"uid":  uid,
+		"id":   id,
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not build hidden account sql query: %w", err)
+	}
+
+	var hidden bool
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&hidden)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not query select account privacy: %w", err)
+	}
+
+	return hidden, nil
+}
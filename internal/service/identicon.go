@@ -0,0 +1,109 @@
+package service
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"net/url"
+	"strconv"
+)
+
+// identiconGrid is the number of cells per side.
+// Only the left half plus the middle column are derived from the hash; the rest is mirrored.
+const identiconGrid = 5
+
+// ErrInvalidAvatarSize denotes an avatar size not in the generated sizes.
+var ErrInvalidAvatarSize = errors.New("invalid avatar size")
+
+// Identicon of the given username as png. A zero size means the main avatar size.
+// It's the default avatar for users that didn't upload one,
+// so the same username always gets the same picture.
+func (s *Service) Identicon(username string, size int) ([]byte, error) {
+	if !reUsername.MatchString(username) {
+		return nil, ErrInvalidUsername
+	}
+
+	if size == 0 {
+		size = avatarSize
+	}
+
+	if !validAvatarSize(size) {
+		return nil, ErrInvalidAvatarSize
+	}
+
+	sum := sha256.Sum256([]byte(username))
+	fg := identiconColor(sum[0], sum[1])
+	bg := color.RGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+
+	// Leave half a cell of padding around the grid.
+	cell := size / (identiconGrid + 1)
+	offset := (size - cell*identiconGrid) / 2
+	for row := 0; row < identiconGrid; row++ {
+		for col := 0; col < (identiconGrid+1)/2; col++ {
+			if sum[2+row*identiconGrid+col]%2 != 0 {
+				continue
+			}
+
+			for _, c := range []int{col, identiconGrid - 1 - col} {
+				x0, y0 := offset+c*cell, offset+row*cell
+				for y := y0; y < y0+cell; y++ {
+					for x := x0; x < x0+cell; x++ {
+						img.SetColorIndex(x, y, 1)
+					}
+				}
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("could not encode identicon: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+func (s *Service) identiconURL(username string, size int) string {
+	u := cloneURL(s.origin)
+	u.Path = "/api/identicons/" + username
+	if size != avatarSize {
+		u.RawQuery = url.Values{"size": []string{strconv.Itoa(size)}}.Encode()
+	}
+	return u.String()
+}
+
+func validAvatarSize(size int) bool {
+	for _, s := range avatarSizes {
+		if s == size {
+			return true
+		}
+	}
+	return false
+}
+
+// identiconColor picks a saturated color out of the given hue bytes.
+func identiconColor(b0, b1 byte) color.RGBA {
+	hue := float64(int(b0)<<8|int(b1)) / 65536 * 6
+	const max, min = 0xd0, 0x50
+	x := hue - float64(int(hue))
+	up := uint8(min + (max-min)*x)
+	down := uint8(max - (max-min)*x)
+	switch int(hue) {
+	case 0:
+		return color.RGBA{R: max, G: up, B: min, A: 0xff}
+	case 1:
+		return color.RGBA{R: down, G: max, B: min, A: 0xff}
+	case 2:
+		return color.RGBA{R: min, G: max, B: up, A: 0xff}
+	case 3:
+		return color.RGBA{R: min, G: down, B: max, A: 0xff}
+	case 4:
+		return color.RGBA{R: up, G: min, B: max, A: 0xff}
+	}
+	return color.RGBA{R: max, G: min, B: down, A: 0xff}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"io/ioutil"
+
+	"github.com/disintegration/imaging"
+	"github.com/lib/pq"
+	gonanoid "github.com/matoous/go-nanoid"
+	_ "golang.org/x/image/webp" // register webp decoder
+)
+
+const (
+	// MaxMediaPerPost is the max number of images attached to a single post.
+	MaxMediaPerPost = 4
+	// MaxJPEGMediaBytes to read from a jpeg attachment.
+	MaxJPEGMediaBytes = 5 << 20 // 5MB
+	// MaxPNGMediaBytes to read from a png attachment.
+	MaxPNGMediaBytes = 5 << 20 // 5MB
+	// MaxGIFMediaBytes to read from a gif attachment.
+	MaxGIFMediaBytes = 15 << 20 // 15MB
+	// MaxWebPMediaBytes to read from a webp attachment.
+	MaxWebPMediaBytes = 5 << 20 // 5MB
+	// MaxMediaBytes to read from any attachment. The biggest of the limits above.
+	MaxMediaBytes = MaxGIFMediaBytes
+
+	maxMediaSize       = 2048
+	mediaThumbnailSize = 400
+	// maxMediaPixels is the max width times height an attachment can declare.
+	// Checked before decoding, since a few bytes can declare a huge image.
+	maxMediaPixels = 40000000
+)
+
+var (
+	// ErrTooManyMedia denotes more attachments than MaxMediaPerPost.
+	ErrTooManyMedia = errors.New("too many media")
+	// ErrUnsupportedMediaFormat denotes an unsupported attachment image format.
+	ErrUnsupportedMediaFormat = errors.New("unsupported media format")
+	// ErrMediaTooLarge denotes an attachment bigger than its format limit
+	// or with more than maxMediaPixels.
+	ErrMediaTooLarge = errors.New("media too large")
+)
+
+// Media model. An image attached to a post.
+type Media struct {
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnailURL"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+}
+
+// storedMedia is a media already written to the blob store.
+type storedMedia struct {
+	File      string
+	Thumbnail string
+	Width     int
+	Height    int
+}
+
+func mediaLimit(format string) (int64, bool) {
+	switch format {
+	case "jpeg":
+		return MaxJPEGMediaBytes, true
+	case "png":
+		return MaxPNGMediaBytes, true
+	case "gif":
+		return MaxGIFMediaBytes, true
+	case "webp":
+		return MaxWebPMediaBytes, true
+	}
+	return 0, false
+}
+
+// storeMedia validates, cleans and writes the given attachments to the blob store.
+// Metadata like EXIF is dropped by decoding and encoding each image again.
+// On error, blobs already written are removed.
+func (s *Service) storeMedia(ctx context.Context, rr []io.Reader) (mm []storedMedia, err error) {
+	if len(rr) > MaxMediaPerPost {
+		return nil, ErrTooManyMedia
+	}
+
+	defer func() {
+		if err != nil {
+			s.removeMedia(mm)
+			mm = nil
+		}
+	}()
+
+	for _, r := range rr {
+		b, err := ioutil.ReadAll(io.LimitReader(r, MaxMediaBytes+1))
+		if err != nil {
+			return mm, fmt.Errorf("could not read media: %w", err)
+		}
+
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(b))
+		if err == image.ErrFormat {
+			return mm, ErrUnsupportedMediaFormat
+		}
+
+		if err != nil {
+			return mm, fmt.Errorf("could not read media config: %w", err)
+		}
+
+		limit, ok := mediaLimit(format)
+		if !ok {
+			return mm, ErrUnsupportedMediaFormat
+		}
+
+		if int64(len(b)) > limit || int64(cfg.Width)*int64(cfg.Height) > maxMediaPixels {
+			return mm, ErrMediaTooLarge
+		}
+
+		m, err := s.writeMedia(ctx, b, format)
+		if err != nil {
+			return mm, err
+		}
+
+		mm = append(mm, m)
+	}
+
+	return mm, nil
+}
+
+func (s *Service) writeMedia(ctx context.Context, b []byte, format string) (storedMedia, error) {
+	var m storedMedia
+	name, err := gonanoid.Nanoid()
+	if err != nil {
+		return m, fmt.Errorf("could not generate media filename: %w", err)
+	}
+
+	var img image.Image
+	var write func(io.Writer) error
+	var contentType string
+	if format == "gif" {
+		// Keep the animation. Only the thumbnail is taken from the first frame.
+		g, err := gif.DecodeAll(bytes.NewReader(b))
+		if err != nil {
+			return m, fmt.Errorf("could not decode gif media: %w", err)
+		}
+
+		if g.Config.Width > maxMediaSize || g.Config.Height > maxMediaSize {
+			g = fitGIF(g, maxMediaSize)
+		}
+
+		img = gifFirstFrame(g)
+		m.File = name + ".gif"
+		m.Width, m.Height = g.Config.Width, g.Config.Height
+		write = func(w io.Writer) error { return gif.EncodeAll(w, g) }
+		contentType = "image/gif"
+	} else {
+		img, err = imaging.Decode(bytes.NewReader(b), imaging.AutoOrientation(true))
+		if err != nil {
+			return m, fmt.Errorf("could not decode media: %w", err)
+		}
+
+		if bounds := img.Bounds(); bounds.Dx() > maxMediaSize || bounds.Dy() > maxMediaSize {
+			img = imaging.Fit(img, maxMediaSize, maxMediaSize, imaging.CatmullRom)
+		}
+
+		full := img
+		ext := imageExt(full, format)
+		m.File = name + ext
+		m.Width, m.Height = full.Bounds().Dx(), full.Bounds().Dy()
+		write = func(w io.Writer) error { return encodeImage(w, full, ext) }
+		contentType = imageContentType(ext)
+	}
+
+	if err = s.putImage(ctx, mediaBlob(m.File), contentType, write); err != nil {
+		return m, err
+	}
+
+	thumb := imaging.Fit(img, mediaThumbnailSize, mediaThumbnailSize, imaging.CatmullRom)
+	thumbExt := imageExt(thumb, format)
+	m.Thumbnail = name + "_thumb" + thumbExt
+	if err = s.putImage(ctx, mediaBlob(m.Thumbnail), imageContentType(thumbExt), func(w io.Writer) error {
+		return encodeImage(w, thumb, thumbExt)
+	}); err != nil {
+		s.deleteBlob(mediaBlob(m.File))
+		return m, err
+	}
+
+	return m, nil
+}
+
+// imageExt picks the extension a still image gets written with.
+// There is no webp encoder, so opaque webp images become jpeg and the rest png.
+func imageExt(img image.Image, format string) string {
+	switch format {
+	case "jpeg":
+		return ".jpg"
+	case "webp":
+		if o, ok := img.(interface{ Opaque() bool }); ok && o.Opaque() {
+			return ".jpg"
+		}
+	}
+	return ".png"
+}
+
+func encodeImage(w io.Writer, img image.Image, ext string) error {
+	if ext == ".jpg" {
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	}
+	return png.Encode(w, img)
+}
+
+func imageContentType(ext string) string {
+	switch ext {
+	case ".jpg":
+		return "image/jpeg"
+	case ".gif":
+		return "image/gif"
+	}
+	return "image/png"
+}
+
+func (s *Service) putImage(ctx context.Context, name, contentType string, write func(io.Writer) error) error {
+	var buf bytes.Buffer
+	if err := write(&buf); err != nil {
+		return fmt.Errorf("could not encode image: %w", err)
+	}
+
+	if err := s.store.Put(ctx, name, &buf, contentType); err != nil {
+		return fmt.Errorf("could not store image: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) removeMedia(mm []storedMedia) {
+	for _, m := range mm {
+		s.deleteBlob(mediaBlob(m.File))
+		s.deleteBlob(mediaBlob(m.Thumbnail))
+	}
+}
+
+func mediaBlob(file string) string {
+	return "media/" + file
+}
+
+// attachPostMedia loads the media of the given posts.
+func (s *Service) attachPostMedia(ctx context.Context, pp ...*Post) error {
+	ids := []string{}
+	byID := map[string][]*Post{}
+	for _, p := range pp {
+		if p == nil || p.Unavailable {
+			continue
+		}
+		ids = append(ids, p.ID)
+		byID[p.ID] = append(byID[p.ID], p)
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT post_id, file, thumbnail, width, height
+		FROM post_media
+		WHERE post_id = ANY($1)
+		ORDER BY post_id, position`, pq.Array(ids))
+	if err != nil {
+		return fmt.Errorf("could not query select post media: %w", err)
+	}
+
+	defer rows.Close()
+
+	for rows.Next() {
+		var postID string
+		var m storedMedia
+		if err = rows.Scan(&postID, &m.File, &m.Thumbnail, &m.Width, &m.Height); err != nil {
+			return fmt.Errorf("could not scan post media: %w", err)
+		}
+
+		for _, p := range byID[postID] {
+			p.Media = append(p.Media, s.media(m))
+		}
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate post media rows: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Service) media(m storedMedia) Media {
+	return Media{
+		URL:          s.store.URL(mediaBlob(m.File)),
+		ThumbnailURL: s.store.URL(mediaBlob(m.Thumbnail)),
+		Width:        m.Width,
+		Height:       m.Height,
+	}
+}
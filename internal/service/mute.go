@@ -0,0 +1,270 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// mutedWordMaxLength is the max number of characters of a muted word or phrase.
+const mutedWordMaxLength = 100
+
+var (
+	// ErrInvalidMuteID denotes an invalid mute id; that is not uuid.
+	ErrInvalidMuteID = errors.New("invalid mute id")
+	// ErrInvalidMutedWord denotes an empty or too long muted word.
+	ErrInvalidMutedWord = errors.New("invalid muted word")
+	// ErrInvalidMuteExpiry denotes a mute expiry time that already passed.
+	ErrInvalidMuteExpiry = errors.New("invalid mute expiry")
+	// ErrForbiddenMute denotes a forbidden mute. Like muting yourself.
+	ErrForbiddenMute = errors.New("forbidden mute")
+	// ErrMuteNotFound denotes a not found mute.
+	ErrMuteNotFound = errors.New("mute not found")
+)
+
+// Mute model.
+// It's either of a user or of a word or phrase.
+// Mutes without expiry last until deleted.
+type Mute struct {
+	ID        string     `json:"id"`
+	User      *User      `json:"user,omitempty"`
+	Word      *string    `json:"word,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// activeMute is the sql condition of a not expired mute.
+const activeMute = "(mutes.expires_at IS NULL OR mutes.expires_at > now())"
+
+// mutedWordPattern is the sql regular expression matching the muted word or phrase
+// between word boundaries, so muting "cat" doesn't hide "category".
+// Regular expression metacharacters in the word get escaped.
+const mutedWordPattern = `('(^|[^\p{L}\p{N}_])'
+	|| regexp_replace(mutes.word, '([\\.+*?()|\[\]{}^$])', '\\\1', 'g')
+	|| '($|[^\p{L}\p{N}_])')`
+
+// MuteUser hides the posts of the given user from the authenticated user timeline
+// and silences the notifications they trigger.
+// Muting an already muted user updates its expiry.
+func (s *Service) MuteUser(ctx context.Context, username string, expiresAt *time.Time) (Mute, error) {
+	var m Mute
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return m, ErrUnauthenticated
+	}
+
+	username = strings.TrimSpace(username)
+	if !reUsername.MatchString(username) {
+		return m, ErrInvalidUsername
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return m, ErrInvalidMuteExpiry
+	}
+
+	var u User
+	var avatar sql.NullString
+	query := "SELECT id, username, avatar FROM users WHERE username = $1"
+	err := s.db.QueryRowContext(ctx, query, username).Scan(&u.ID, &u.Username, &avatar)
+	if err == sql.ErrNoRows {
+		return m, ErrUserNotFound
+	}
+
+	if err != nil {
+		return m, fmt.Errorf("could not query select user to mute: %w", err)
+	}
+
+	if u.ID == uid {
+		return m, ErrForbiddenMute
+	}
+
+	query = `
+		INSERT INTO mutes (user_id, muted_user_id, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, muted_user_id) DO UPDATE SET expires_at = excluded.expires_at
+		RETURNING id, expires_at, created_at`
+	row := s.db.QueryRowContext(ctx, query, uid, u.ID, expiresAt)
+	if err = row.Scan(&m.ID, &m.ExpiresAt, &m.CreatedAt); err != nil {
+		return m, fmt.Errorf("could not insert user mute: %w", err)
+	}
+
+	u.ID = ""
+	u.AvatarURL = s.avatarURL(u.Username, avatar)
+	u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+	m.User = &u
+
+	return m, nil
+}
+
+// MuteWord hides the posts containing the given word or phrase
+// from the authenticated user timeline. Matching is case insensitive.
+// Muting an already muted word updates its expiry.
+func (s *Service) MuteWord(ctx context.Context, word string, expiresAt *time.Time) (Mute, error) {
+	var m Mute
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return m, ErrUnauthenticated
+	}
+
+	word = strings.ToLower(strings.Join(strings.Fields(word), " "))
+	if word == "" || utf8.RuneCountInString(word) > mutedWordMaxLength {
+		return m, ErrInvalidMutedWord
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return m, ErrInvalidMuteExpiry
+	}
+
+	query := `
+		INSERT INTO mutes (user_id, word, expires_at) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, word) DO UPDATE SET expires_at = excluded.expires_at
+		RETURNING id, expires_at, created_at`
+	row := s.db.QueryRowContext(ctx, query, uid, word, expiresAt)
+	if err := row.Scan(&m.ID, &m.ExpiresAt, &m.CreatedAt); err != nil {
+		return m, fmt.Errorf("could not insert word mute: %w", err)
+	}
+
+	m.Word = &word
+
+	return m, nil
+}
+
+// Mutes of the authenticated user that didn't expire yet, the newest first.
+func (s *Service) Mutes(ctx context.Context) ([]Mute, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	query := `
+		SELECT mutes.id, mutes.word, mutes.expires_at, mutes.created_at
+		, users.username, users.avatar
+		FROM mutes
+		LEFT JOIN users ON mutes.muted_user_id = users.id
+		WHERE mutes.user_id = $1 AND ` + activeMute + `
+		ORDER BY mutes.created_at DESC`
+	rows, err := s.db.QueryContext(ctx, query, uid)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select mutes: %w", err)
+	}
+
+	defer rows.Close()
+
+	var mm []Mute
+	for rows.Next() {
+		var m Mute
+		var username, avatar sql.NullString
+		if err = rows.Scan(&m.ID, &m.Word, &m.ExpiresAt, &m.CreatedAt, &username, &avatar); err != nil {
+			return nil, fmt.Errorf("could not scan mute: %w", err)
+		}
+
+		if username.Valid {
+			m.User = &User{
+				Username:   username.String,
+				AvatarURL:  s.avatarURL(username.String, avatar),
+				AvatarURLs: s.avatarURLs(username.String, avatar),
+			}
+		}
+
+		mm = append(mm, m)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate mute rows: %w", err)
+	}
+
+	return mm, nil
+}
+
+// UpdateMute expiry. A nil expiry makes the mute last until deleted.
+func (s *Service) UpdateMute(ctx context.Context, muteID string, expiresAt *time.Time) (Mute, error) {
+	var m Mute
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return m, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(muteID) {
+		return m, ErrInvalidMuteID
+	}
+
+	if expiresAt != nil && !expiresAt.After(time.Now()) {
+		return m, ErrInvalidMuteExpiry
+	}
+
+	var mutedUserID *string
+	query := `
+		UPDATE mutes SET expires_at = $1
+		WHERE id = $2 AND user_id = $3
+		RETURNING id, muted_user_id, word, expires_at, created_at`
+	row := s.db.QueryRowContext(ctx, query, expiresAt, muteID, uid)
+	err := row.Scan(&m.ID, &mutedUserID, &m.Word, &m.ExpiresAt, &m.CreatedAt)
+	if err == sql.ErrNoRows {
+		return m, ErrMuteNotFound
+	}
+
+	if err != nil {
+		return m, fmt.Errorf("could not update mute: %w", err)
+	}
+
+	if mutedUserID != nil {
+		u, err := s.userByID(ctx, *mutedUserID)
+		if err != nil {
+			return m, err
+		}
+
+		u.ID = ""
+		m.User = &u
+	}
+
+	return m, nil
+}
+
+// DeleteMute of the authenticated user.
+func (s *Service) DeleteMute(ctx context.Context, muteID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(muteID) {
+		return ErrInvalidMuteID
+	}
+
+	query := "DELETE FROM mutes WHERE id = $1 AND user_id = $2"
+	if _, err := s.db.ExecContext(ctx, query, muteID, uid); err != nil {
+		return fmt.Errorf("could not delete mute: %w", err)
+	}
+
+	return nil
+}
+
+// mutedTimelineItem reports whether the given user muted the author
+// or the reposter of the timeline item, or any word in its content.
+func (s *Service) mutedTimelineItem(ctx context.Context, userID string, ti TimelineItem) (bool, error) {
+	if ti.Post == nil {
+		return false, nil
+	}
+
+	var reposterID *string
+	if ti.RepostedBy != nil {
+		reposterID = &ti.RepostedBy.ID
+	}
+
+	var muted bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM mutes
+			WHERE mutes.user_id = $1 AND ` + activeMute + `
+				AND (mutes.muted_user_id = $2 OR mutes.muted_user_id = $3 OR lower($4) ~ ` + mutedWordPattern + `)
+		)`
+	row := s.db.QueryRowContext(ctx, query, userID, ti.Post.UserID, reposterID, ti.Post.Content)
+	if err := row.Scan(&muted); err != nil {
+		return false, fmt.Errorf("could not query select timeline item mute existence: %w", err)
+	}
+
+	return muted, nil
+}
@@ -29,15 +29,26 @@ type Notification struct {
 	IssuedAt time.Time `json:"issuedAt"`
 }
 
+// NotificationsOutput response.
+type NotificationsOutput struct {
+	Items []Notification `json:"items"`
+	PageInfo
+}
+
 // Notifications from the authenticated user in descending order with backward pagination.
-func (s *Service) Notifications(ctx context.Context, last int, before string) ([]Notification, error) {
+func (s *Service) Notifications(ctx context.Context, last int, before string) (NotificationsOutput, error) {
+	var out NotificationsOutput
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
-		return nil, ErrUnauthenticated
+		return out, ErrUnauthenticated
 	}
 
-	if before != "" && !reUUID.MatchString(before) {
-		return nil, ErrInvalidNotificationID
+	var cursor timeCursor
+	if before != "" {
+		var err error
+		if cursor, err = decodeTimeCursor(before); err != nil {
+			return out, err
+		}
 	}
 
 	last = normalizePageSize(last)
@@ -45,30 +56,32 @@ func (s *Service) Notifications(ctx context.Context, last int, before string) ([
 		SELECT id, actors, type, post_id, read_at, issued_at
 		FROM notifications
 		WHERE user_id = @uid
-		{{if .before}}AND id < @before{{end}}
-		ORDER BY issued_at DESC
-		LIMIT @last`, map[string]interface{}{
-		"uid":    uid,
-		"before": before,
-		"last":   last,
+		{{if .before}}AND (issued_at, id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+		ORDER BY issued_at DESC, id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"uid":        uid,
+		"before":     before != "",
+		"cursorTime": cursor.Time,
+		"cursorID":   cursor.ID,
+		"limit":      last + 1,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("could not build notifications sql query: %w", err)
+		return out, fmt.Errorf("could not build notifications sql query: %w", err)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select notifications: %w", err)
+		return out, fmt.Errorf("could not query select notifications: %w", err)
 	}
 
 	defer rows.Close()
 
-	nn := make([]Notification, 0, last)
+	nn := make([]Notification, 0, last+1)
 	for rows.Next() {
 		var n Notification
 		var readAt *time.Time
 		if err = rows.Scan(&n.ID, pq.Array(&n.Actors), &n.Type, &n.PostID, &readAt, &n.IssuedAt); err != nil {
-			return nil, fmt.Errorf("could not scan notification: %w", err)
+			return out, fmt.Errorf("could not scan notification: %w", err)
 		}
 
 		n.Read = readAt != nil && !readAt.IsZero()
@@ -76,10 +89,16 @@ func (s *Service) Notifications(ctx context.Context, last int, before string) ([
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate over notification rows: %w", err)
+		return out, fmt.Errorf("could not iterate over notification rows: %w", err)
 	}
 
-	return nn, nil
+	if len(nn) > last {
+		nn = nn[:last]
+		out.PageInfo = nextPage(timeCursor{Time: nn[last-1].IssuedAt, ID: nn[last-1].ID})
+	}
+
+	out.Items = nn
+	return out, nil
 }
 
 // NotificationStream to receive notifications in realtime.
@@ -172,11 +191,38 @@ func (s *Service) MarkNotificationsAsRead(ctx context.Context) error {
 }
 
 func (s *Service) notifyFollow(followerID, followeeID string) {
+	s.notifyFollowing(followerID, followeeID, "follow")
+}
+
+func (s *Service) notifyFollowRequest(followerID, followeeID string) {
+	s.notifyFollowing(followerID, followeeID, "follow_request")
+}
+
+// notifyFollowing notifies the followee about a follow or a follow request.
+// Unread notifications of the same type get grouped and every actor notifies only once.
+func (s *Service) notifyFollowing(followerID, followeeID, typ string) {
 	ctx := context.Background()
 	var n Notification
+	var silenced bool
 	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		query := `SELECT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = $1 AND blocked_id = $2)
+				OR (blocker_id = $2 AND blocked_id = $1)
+		) OR EXISTS (
+			SELECT 1 FROM mutes
+			WHERE mutes.user_id = $1 AND mutes.muted_user_id = $2 AND ` + activeMute + `
+		)`
+		if err := tx.QueryRowContext(ctx, query, followeeID, followerID).Scan(&silenced); err != nil {
+			return fmt.Errorf("could not query select follow notification block or mute existence: %w", err)
+		}
+
+		if silenced {
+			return nil
+		}
+
 		var actor string
-		query := "SELECT username FROM users WHERE id = $1"
+		query = "SELECT username FROM users WHERE id = $1"
 		err := tx.QueryRowContext(ctx, query, followerID).Scan(&actor)
 		if err != nil {
 			return fmt.Errorf("could not query select follow notification actor: %w", err)
@@ -188,9 +234,9 @@ func (s *Service) notifyFollow(followerID, followeeID string) {
 			SELECT 1 FROM notifications
 			WHERE user_id = $1
 				AND $2:::VARCHAR = ANY(actors)
-				AND type = 'follow'
+				AND type = $3
 		)`
-		err = tx.QueryRowContext(ctx, query, followeeID, actor).Scan(&notified)
+		err = tx.QueryRowContext(ctx, query, followeeID, actor, typ).Scan(&notified)
 		if err != nil {
 			return fmt.Errorf("could not query select follow notification existence: %w", err)
 		}
@@ -200,8 +246,8 @@ func (s *Service) notifyFollow(followerID, followeeID string) {
 		}
 
 		var nid string
-		query = "SELECT id FROM notifications WHERE user_id = $1 AND type = 'follow' AND read_at IS NULL"
-		err = tx.QueryRowContext(ctx, query, followeeID).Scan(&nid)
+		query = "SELECT id FROM notifications WHERE user_id = $1 AND type = $2 AND read_at IS NULL"
+		err = tx.QueryRowContext(ctx, query, followeeID, typ).Scan(&nid)
 		if err != nil && err != sql.ErrNoRows {
 			return fmt.Errorf("could not query select unread follow notification: %w", err)
 		}
@@ -209,9 +255,9 @@ func (s *Service) notifyFollow(followerID, followeeID string) {
 		if err == sql.ErrNoRows {
 			actors := []string{actor}
 			query = `
-				INSERT INTO notifications (user_id, actors, type) VALUES ($1, $2, 'follow')
+				INSERT INTO notifications (user_id, actors, type) VALUES ($1, $2, $3)
 				RETURNING id, issued_at`
-			row := tx.QueryRowContext(ctx, query, followeeID, pq.Array(actors))
+			row := tx.QueryRowContext(ctx, query, followeeID, pq.Array(actors), typ)
 			err = row.Scan(&n.ID, &n.IssuedAt)
 			if err != nil {
 				return fmt.Errorf("could not insert follow notification: %w", err)
@@ -235,12 +281,16 @@ func (s *Service) notifyFollow(followerID, followeeID string) {
 		}
 
 		n.UserID = followeeID
-		n.Type = "follow"
+		n.Type = typ
 
 		return nil
 	})
 	if err != nil {
-		log.Printf("could not notify follow: %v\n", err)
+		log.Printf("could not notify %s: %v\n", typ, err)
+		return
+	}
+
+	if silenced || n.ID == "" {
 		return
 	}
 
@@ -254,6 +304,15 @@ func (s *Service) notifyComment(c Comment) {
 		SELECT user_id, $1, 'comment', $2 FROM post_subscriptions
 		WHERE post_subscriptions.user_id != $3
 			AND post_subscriptions.post_id = $2
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks
+				WHERE (blocker_id = post_subscriptions.user_id AND blocked_id = $3)
+					OR (blocker_id = $3 AND blocked_id = post_subscriptions.user_id)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM mutes
+				WHERE mutes.user_id = post_subscriptions.user_id AND mutes.muted_user_id = $3 AND `+activeMute+`
+			)
 		ON CONFLICT (user_id, type, post_id, read_at) DO UPDATE SET
 			actors = array_prepend($4, array_remove(notifications.actors, $4)),
 			issued_at = now()
@@ -289,8 +348,7 @@ func (s *Service) notifyComment(c Comment) {
 	}
 }
 
-func (s *Service) notifyPostMention(p Post) {
-	mentions := collectMentions(p.Content)
+func (s *Service) notifyPostMention(p Post, mentions []string) {
 	if len(mentions) == 0 {
 		return
 	}
@@ -301,6 +359,15 @@ func (s *Service) notifyPostMention(p Post) {
 		SELECT users.id, $1, 'post_mention', $2 FROM users
 		WHERE users.id != $3
 			AND username = ANY($4)
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks
+				WHERE (blocker_id = users.id AND blocked_id = $3)
+					OR (blocker_id = $3 AND blocked_id = users.id)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM mutes
+				WHERE mutes.user_id = users.id AND mutes.muted_user_id = $3 AND `+activeMute+`
+			)
 		RETURNING id, user_id, issued_at`,
 		pq.Array(actors),
 		p.ID,
@@ -334,8 +401,7 @@ func (s *Service) notifyPostMention(p Post) {
 	}
 }
 
-func (s *Service) notifyCommentMention(c Comment) {
-	mentions := collectMentions(c.Content)
+func (s *Service) notifyCommentMention(c Comment, mentions []string) {
 	if len(mentions) == 0 {
 		return
 	}
@@ -346,6 +412,15 @@ func (s *Service) notifyCommentMention(c Comment) {
 		SELECT users.id, $1, 'comment_mention', $2 FROM users
 		WHERE users.id != $3
 			AND username = ANY($4)
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks
+				WHERE (blocker_id = users.id AND blocked_id = $3)
+					OR (blocker_id = $3 AND blocked_id = users.id)
+			)
+			AND NOT EXISTS (
+				SELECT 1 FROM mutes
+				WHERE mutes.user_id = users.id AND mutes.muted_user_id = $3 AND `+activeMute+`
+			)
 		ON CONFLICT (user_id, type, post_id, read_at) DO UPDATE SET
 			actors = array_prepend($5, array_remove(notifications.actors, $5)),
 			issued_at = now()
@@ -382,6 +457,90 @@ func (s *Service) notifyCommentMention(c Comment) {
 	}
 }
 
+// notifiable is the sql condition of the user $1 accepting notifications
+// from the actor $5: neither blocks the other nor $1 muted $5.
+const notifiable = `NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = $1 AND blocked_id = $5)
+			OR (blocker_id = $5 AND blocked_id = $1)
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.user_id = $1 AND mutes.muted_user_id = $5 AND ` + activeMute + `
+	)`
+
+func (s *Service) notifyCommentReply(c Comment, parentUserID string) {
+	if parentUserID == c.UserID {
+		return
+	}
+
+	actor := c.User.Username
+	var n Notification
+	err := s.db.QueryRow(`
+		INSERT INTO notifications (user_id, actors, type, post_id)
+		SELECT $1, $2, 'comment_reply', $3
+		WHERE `+notifiable+`
+		ON CONFLICT (user_id, type, post_id, read_at) DO UPDATE SET
+			actors = array_prepend($4, array_remove(notifications.actors, $4)),
+			issued_at = now()
+		RETURNING id, actors, issued_at`,
+		parentUserID,
+		pq.Array([]string{actor}),
+		c.PostID,
+		actor,
+		c.UserID,
+	).Scan(&n.ID, pq.Array(&n.Actors), &n.IssuedAt)
+	// Blocked or muted.
+	if err == sql.ErrNoRows {
+		return
+	}
+
+	if err != nil {
+		log.Printf("could not insert comment reply notification: %v\n", err)
+		return
+	}
+
+	n.UserID = parentUserID
+	n.Type = "comment_reply"
+	n.PostID = &c.PostID
+
+	go s.broadcastNotification(n)
+}
+
+func (s *Service) notifyRepost(p Post, reposter User) {
+	actor := reposter.Username
+	var n Notification
+	err := s.db.QueryRow(`
+		INSERT INTO notifications (user_id, actors, type, post_id)
+		SELECT $1, $2, 'repost', $3
+		WHERE `+notifiable+`
+		ON CONFLICT (user_id, type, post_id, read_at) DO UPDATE SET
+			actors = array_prepend($4, array_remove(notifications.actors, $4)),
+			issued_at = now()
+		RETURNING id, actors, issued_at`,
+		p.UserID,
+		pq.Array([]string{actor}),
+		p.ID,
+		actor,
+		reposter.ID,
+	).Scan(&n.ID, pq.Array(&n.Actors), &n.IssuedAt)
+	// Blocked or muted.
+	if err == sql.ErrNoRows {
+		return
+	}
+
+	if err != nil {
+		log.Printf("could not insert repost notification: %v\n", err)
+		return
+	}
+
+	n.UserID = p.UserID
+	n.Type = "repost"
+	n.PostID = &p.ID
+
+	go s.broadcastNotification(n)
+}
+
 func (s *Service) broadcastNotification(n Notification) {
 	var b bytes.Buffer
 	err := gob.NewEncoder(&b).Encode(n)
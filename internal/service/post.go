@@ -5,12 +5,14 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"strings"
 	"time"
 	"unicode/utf8"
 
 	"github.com/cockroachdb/cockroach-go/crdb"
+	"github.com/lib/pq"
 )
 
 var (
@@ -23,6 +25,8 @@ var (
 	ErrInvalidSpoiler = errors.New("invalid spoiler")
 	// ErrPostNotFound denotes a not found post.
 	ErrPostNotFound = errors.New("post not found")
+	// ErrForbiddenRepost denotes a forbidden repost. Like reposting your own post.
+	ErrForbiddenRepost = errors.New("forbidden repost")
 )
 
 // Post model.
@@ -31,14 +35,38 @@ type Post struct {
 	UserID        string    `json:"-"`
 	Content       string    `json:"content"`
 	SpoilerOf     *string   `json:"spoilerOf"`
-	NSFW          bool      `json:"NSFW"`          //是否有安全警告，有些帖子会被标注为不安全的帖子
+	NSFW          bool      `json:"NSFW"` //是否有安全警告，有些帖子会被标注为不安全的帖子
+	Visibility    string    `json:"visibility"`
 	LikesCount    int       `json:"likesCount"`    //点赞数
 	CommentsCount int       `json:"commentsCount"` // 评论数
-	CreatedAt     time.Time `json:"createdAt"`     // 帖子发布时间
+	RepostsCount  int       `json:"repostsCount"`
+	QuotedPostID  *string   `json:"-"`
+	QuotedPost    *Post     `json:"quotedPost,omitempty"`
+	Media         []Media   `json:"media,omitempty"`
+	Unavailable   bool      `json:"unavailable,omitempty"` // placeholder for a quoted post that was deleted or can't be seen
+	CreatedAt     time.Time `json:"createdAt"`             // 帖子发布时间
 	User          *User     `json:"user,omitempty"`
 	Mine          bool      `json:"mine"`       // 是否是当前用户自己发的帖子
 	Liked         bool      `json:"liked"`      // 当前用户是否点赞了这个帖子
 	Subscribed    bool      `json:"subscribed"` // 当前用户是否订阅了这个帖子（也可以说是收藏）
+	Reposted      bool      `json:"reposted"`
+}
+
+// PostsOutput response.
+type PostsOutput struct {
+	Items []Post `json:"items"`
+	PageInfo
+}
+
+// PostRevision model.
+// It holds how a post looked before one of its edits.
+type PostRevision struct {
+	ID        string    `json:"id"`
+	PostID    string    `json:"-"`
+	Content   string    `json:"content"`
+	SpoilerOf *string   `json:"spoilerOf"`
+	NSFW      bool      `json:"NSFW"`
+	CreatedAt time.Time `json:"createdAt"`
 }
 
 // ToggleLikeOutput response.
@@ -47,13 +75,21 @@ type ToggleLikeOutput struct {
 	LikesCount int  `json:"likesCount"`
 }
 
+// RepostOutput response.
+type RepostOutput struct {
+	Reposted     bool `json:"reposted"`
+	RepostsCount int  `json:"repostsCount"`
+}
+
 // ToggleSubscriptionOutput response.
 type ToggleSubscriptionOutput struct {
 	Subscribed bool `json:"subscribed"`
 }
 
 // CreatePost publishes a post to the user timeline and fan-outs it to his followers.
-func (s *Service) CreatePost(ctx context.Context, content string, spoilerOf *string, nsfw bool) (TimelineItem, error) {
+// A post can optionally quote another one the user can see and have up to MaxMediaPerPost images attached.
+// Visibility defaults to public; mentioned only posts fan-out to the mentioned users instead.
+func (s *Service) CreatePost(ctx context.Context, content string, spoilerOf *string, nsfw bool, visibility string, quotedPostID *string, media []io.Reader) (TimelineItem, error) {
 	var ti TimelineItem
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
@@ -72,24 +108,97 @@ func (s *Service) CreatePost(ctx context.Context, content string, spoilerOf *str
 		}
 	}
 
+	if visibility == "" {
+		visibility = VisibilityPublic
+	}
+
+	if !validVisibility(visibility) {
+		return ti, ErrInvalidVisibility
+	}
+
+	if quotedPostID != nil && !reUUID.MatchString(*quotedPostID) {
+		return ti, ErrInvalidPostID
+	}
+
+	mm, err := s.storeMedia(ctx, media)
+	if err != nil {
+		return ti, err
+	}
+
 	var p Post
-	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+	err = crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		if quotedPostID != nil {
+			// Only posts the author can see can be quoted.
+			query, args, err := buildQuery(`
+				SELECT EXISTS (
+					SELECT 1 FROM posts
+					INNER JOIN users ON posts.user_id = users.id
+					WHERE posts.id = @quotedPostID
+					AND NOT EXISTS (
+						SELECT 1 FROM blocks
+						WHERE (blocker_id = posts.user_id AND blocked_id = @uid)
+							OR (blocker_id = @uid AND blocked_id = posts.user_id)
+					)
+					AND (NOT users.private OR users.id = @uid OR EXISTS (
+						SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = users.id
+					))
+					AND `+visiblePost+`
+				)`, map[string]interface{}{
+				"auth":         true,
+				"uid":          uid,
+				"quotedPostID": *quotedPostID,
+			})
+			if err != nil {
+				return fmt.Errorf("could not build quoted post existence sql query: %w", err)
+			}
+
+			var exists bool
+			if err := tx.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+				return fmt.Errorf("could not query select quoted post existence: %w", err)
+			}
+
+			if !exists {
+				return ErrPostNotFound
+			}
+		}
+
 		// 这个sql表示如果插入成功返回id和 created_at 2个字段。
+		// 粉丝太多的用户发的帖子不写入粉丝的 timeline，而是在读取 Timeline 时合并进去。
 		query := `
-			INSERT INTO posts (user_id, content, spoiler_of, nsfw) VALUES ($1, $2, $3, $4)
+			INSERT INTO posts (user_id, content, spoiler_of, nsfw, visibility, quoted_post_id, fanned_out)
+			VALUES ($1, $2, $3, $4, $5, $6, $5 = 'mentioned' OR (SELECT followers_count <= $7 FROM users WHERE id = $1))
 			RETURNING id, created_at`
-		row := tx.QueryRowContext(ctx, query, uid, content, spoilerOf, nsfw)
+		row := tx.QueryRowContext(ctx, query, uid, content, spoilerOf, nsfw, visibility, quotedPostID, fanoutThreshold)
 		err := row.Scan(&p.ID, &p.CreatedAt)
 		if err != nil {
 			return fmt.Errorf("could not insert post: %w", err)
 		}
 
+		if err = insertPostMentions(ctx, tx, p.ID, uid, collectMentions(content)); err != nil {
+			return err
+		}
+
+		if err = insertTags(ctx, tx, p.ID, nil, collectTags(content)); err != nil {
+			return err
+		}
+
 		p.UserID = uid
 		p.Content = content
 		p.SpoilerOf = spoilerOf
 		p.NSFW = nsfw
+		p.Visibility = visibility
+		p.QuotedPostID = quotedPostID
 		p.Mine = true
 
+		for i, m := range mm {
+			query = `
+				INSERT INTO post_media (post_id, position, file, thumbnail, width, height)
+				VALUES ($1, $2, $3, $4, $5, $6)`
+			if _, err = tx.ExecContext(ctx, query, p.ID, i, m.File, m.Thumbnail, m.Width, m.Height); err != nil {
+				return fmt.Errorf("could not insert post media: %w", err)
+			}
+		}
+
 		query = "INSERT INTO post_subscriptions (user_id, post_id) VALUES ($1, $2)"
 		if _, err = tx.ExecContext(ctx, query, uid, p.ID); err != nil {
 			return fmt.Errorf("could not insert post subscription: %w", err)
@@ -110,6 +219,15 @@ func (s *Service) CreatePost(ctx context.Context, content string, spoilerOf *str
 		return nil
 	})
 	if err != nil {
+		s.removeMedia(mm)
+		return ti, err
+	}
+
+	for _, m := range mm {
+		p.Media = append(p.Media, s.media(m))
+	}
+
+	if err = s.attachQuotedPosts(ctx, &p); err != nil {
 		return ti, err
 	}
 
@@ -132,29 +250,44 @@ func (s *Service) postCreated(p Post) {
 	p.Subscribed = false
 
 	go s.fanoutPost(p)
-	go s.notifyPostMention(p)
+	go s.notifyPostMention(p, collectMentions(p.Content))
 }
 
 // Posts from a user in descending order and with backward pagination.
 // 根据用户名获取到一个用户发表的所有帖子
-func (s *Service) Posts(ctx context.Context, username string, last int, before string) ([]Post, error) {
+func (s *Service) Posts(ctx context.Context, username string, last int, before string) (PostsOutput, error) {
+	var out PostsOutput
 	username = strings.TrimSpace(username)
 	if !reUsername.MatchString(username) {
-		return nil, ErrInvalidUsername
+		return out, ErrInvalidUsername
 	}
 
-	if before != "" && !reUUID.MatchString(before) {
-		return nil, ErrInvalidPostID
+	var cursor timeCursor
+	if before != "" {
+		var err error
+		if cursor, err = decodeTimeCursor(before); err != nil {
+			return out, err
+		}
+	}
+
+	hidden, err := s.hiddenAccount(ctx, ownerByUsername, username)
+	if err != nil {
+		return out, err
+	}
+
+	if hidden {
+		return out, ErrPrivateAccount
 	}
 
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	last = normalizePageSize(last)
 	query, args, err := buildQuery(`
-		SELECT id, content, spoiler_of, nsfw, likes_count, comments_count, created_at
+		SELECT id, content, spoiler_of, nsfw, visibility, likes_count, comments_count, reposts_count, quoted_post_id, created_at
 		{{if .auth}}
 		, posts.user_id = @uid AS mine
 		, likes.user_id IS NOT NULL AS liked
 		, subscriptions.user_id IS NOT NULL AS subscribed
+		, reposts.user_id IS NOT NULL AS reposted
 		{{end}}
 		FROM posts
 		{{if .auth}}
@@ -162,29 +295,41 @@ func (s *Service) Posts(ctx context.Context, username string, last int, before s
 			ON likes.user_id = @uid AND likes.post_id = posts.id
 		LEFT JOIN post_subscriptions AS subscriptions
 			ON subscriptions.user_id = @uid AND subscriptions.post_id = posts.id
+		LEFT JOIN reposts
+			ON reposts.user_id = @uid AND reposts.post_id = posts.id
 		{{end}}
 		WHERE posts.user_id = (SELECT id FROM users WHERE username = @username)
-		{{if .before}}AND posts.id < @before{{end}}
-		ORDER BY created_at DESC
-		LIMIT @last`, map[string]interface{}{
-		"auth":     auth,
-		"uid":      uid,
-		"username": username,
-		"last":     last,
-		"before":   before,
+		AND `+visiblePost+`
+		{{if .auth}}
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = posts.user_id AND blocked_id = @uid)
+				OR (blocker_id = @uid AND blocked_id = posts.user_id)
+		)
+		{{end}}
+		{{if .before}}AND (posts.created_at, posts.id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+		ORDER BY posts.created_at DESC, posts.id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"auth":       auth,
+		"uid":        uid,
+		"username":   username,
+		"limit":      last + 1,
+		"before":     before != "",
+		"cursorTime": cursor.Time,
+		"cursorID":   cursor.ID,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("could not build posts sql query: %w", err)
+		return out, fmt.Errorf("could not build posts sql query: %w", err)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select posts: %w", err)
+		return out, fmt.Errorf("could not query select posts: %w", err)
 	}
 
 	defer rows.Close()
 
-	pp := make([]Post, 0, last)
+	pp := make([]Post, 0, last+1)
 	for rows.Next() {
 		var p Post
 		dest := []interface{}{
@@ -192,26 +337,43 @@ func (s *Service) Posts(ctx context.Context, username string, last int, before s
 			&p.Content,
 			&p.SpoilerOf,
 			&p.NSFW,
+			&p.Visibility,
 			&p.LikesCount,
 			&p.CommentsCount,
+			&p.RepostsCount,
+			&p.QuotedPostID,
 			&p.CreatedAt,
 		}
 		if auth {
-			dest = append(dest, &p.Mine, &p.Liked, &p.Subscribed)
+			dest = append(dest, &p.Mine, &p.Liked, &p.Subscribed, &p.Reposted)
 		}
 
 		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan post: %w", err)
+			return out, fmt.Errorf("could not scan post: %w", err)
 		}
 
 		pp = append(pp, p)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate posts rows: %w", err)
+		return out, fmt.Errorf("could not iterate posts rows: %w", err)
+	}
+
+	if len(pp) > last {
+		pp = pp[:last]
+		out.PageInfo = nextPage(timeCursor{Time: pp[last-1].CreatedAt, ID: pp[last-1].ID})
 	}
 
-	return pp, nil
+	quoting := make([]*Post, len(pp))
+	for i := range pp {
+		quoting[i] = &pp[i]
+	}
+	if err = s.attachPostDetails(ctx, quoting...); err != nil {
+		return out, err
+	}
+
+	out.Items = pp
+	return out, nil
 }
 
 // Post with the given ID.
@@ -224,12 +386,13 @@ func (s *Service) Post(ctx context.Context, postID string) (Post, error) {
 
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	query, args, err := buildQuery(`
-		SELECT posts.id, content, spoiler_of, nsfw, likes_count, comments_count, created_at
+		SELECT posts.id, content, spoiler_of, nsfw, visibility, likes_count, comments_count, reposts_count, quoted_post_id, posts.created_at
 		, users.username, users.avatar
 		{{if .auth}}
 		, posts.user_id = @uid AS mine
 		, likes.user_id IS NOT NULL AS liked
 		, subscriptions.user_id IS NOT NULL AS subscribed
+		, reposts.user_id IS NOT NULL AS reposted
 		{{end}}
 		FROM posts
 		INNER JOIN users ON posts.user_id = users.id
@@ -238,8 +401,21 @@ func (s *Service) Post(ctx context.Context, postID string) (Post, error) {
 			ON likes.user_id = @uid AND likes.post_id = posts.id
 		LEFT JOIN post_subscriptions AS subscriptions
 			ON subscriptions.user_id = @uid AND subscriptions.post_id = posts.id
+		LEFT JOIN reposts
+			ON reposts.user_id = @uid AND reposts.post_id = posts.id
 		{{end}}
-		WHERE posts.id = @post_id`, map[string]interface{}{
+		WHERE posts.id = @post_id
+		{{if .auth}}
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = posts.user_id AND blocked_id = @uid)
+				OR (blocker_id = @uid AND blocked_id = posts.user_id)
+		)
+		{{end}}
+		AND (NOT users.private{{if .auth}} OR users.id = @uid OR EXISTS (
+			SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = users.id
+		){{end}})
+		AND `+visiblePost, map[string]interface{}{
 		"auth":    auth,
 		"uid":     uid,
 		"post_id": postID,
@@ -255,14 +431,17 @@ func (s *Service) Post(ctx context.Context, postID string) (Post, error) {
 		&p.Content,
 		&p.SpoilerOf,
 		&p.NSFW,
+		&p.Visibility,
 		&p.LikesCount,
 		&p.CommentsCount,
+		&p.RepostsCount,
+		&p.QuotedPostID,
 		&p.CreatedAt,
 		&u.Username,
 		&avatar,
 	}
 	if auth {
-		dest = append(dest, &p.Mine, &p.Liked, &p.Subscribed)
+		dest = append(dest, &p.Mine, &p.Liked, &p.Subscribed, &p.Reposted)
 	}
 	err = s.db.QueryRowContext(ctx, query, args...).Scan(dest...)
 	if err == sql.ErrNoRows {
@@ -273,12 +452,446 @@ func (s *Service) Post(ctx context.Context, postID string) (Post, error) {
 		return p, fmt.Errorf("could not query select post: %w", err)
 	}
 
-	u.AvatarURL = s.avatarURL(avatar)
+	u.AvatarURL = s.avatarURL(u.Username, avatar)
+	u.AvatarURLs = s.avatarURLs(u.Username, avatar)
 	p.User = &u
 
+	if err = s.attachPostDetails(ctx, &p); err != nil {
+		return p, err
+	}
+
+	return p, nil
+}
+
+// attachPostDetails loads what doesn't come in the main posts query:
+// the quoted posts and the media of both the posts and their quoted posts.
+func (s *Service) attachPostDetails(ctx context.Context, pp ...*Post) error {
+	if err := s.attachQuotedPosts(ctx, pp...); err != nil {
+		return err
+	}
+
+	all := pp
+	for _, p := range pp {
+		if p.QuotedPost != nil {
+			all = append(all, p.QuotedPost)
+		}
+	}
+
+	return s.attachPostMedia(ctx, all...)
+}
+
+// attachQuotedPosts embeds the quoted post, one level deep, into the given posts.
+// Quoted posts that no longer exist are attached as unavailable placeholders.
+func (s *Service) attachQuotedPosts(ctx context.Context, pp ...*Post) error {
+	ids := []string{}
+	for _, p := range pp {
+		if p.QuotedPostID != nil {
+			ids = append(ids, *p.QuotedPostID)
+		}
+	}
+
+	if len(ids) == 0 {
+		return nil
+	}
+
+	// Posts from users blocked either way, from private accounts
+	// the viewer doesn't follow or not visible to the viewer show as unavailable.
+	var viewer interface{}
+	if uid, ok := ctx.Value(KeyAuthUserID).(string); ok {
+		viewer = uid
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT posts.id, content, spoiler_of, nsfw, visibility, likes_count, comments_count, reposts_count, posts.created_at
+		, users.username, users.avatar
+		FROM posts
+		INNER JOIN users ON posts.user_id = users.id
+		WHERE posts.id = ANY($1)
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = posts.user_id AND blocked_id = $2)
+				OR (blocker_id = $2 AND blocked_id = posts.user_id)
+		)
+		AND (NOT users.private OR users.id = $2 OR EXISTS (
+			SELECT 1 FROM follows WHERE follower_id = $2 AND followee_id = users.id
+		))
+		AND (posts.visibility = 'public' OR posts.user_id = $2
+			OR (posts.visibility = 'followers' AND EXISTS (
+				SELECT 1 FROM follows WHERE follower_id = $2 AND followee_id = posts.user_id
+			))
+			OR EXISTS (SELECT 1 FROM post_mentions WHERE post_mentions.post_id = posts.id AND post_mentions.user_id = $2))`,
+		pq.Array(ids), viewer)
+	if err != nil {
+		return fmt.Errorf("could not query select quoted posts: %w", err)
+	}
+
+	defer rows.Close()
+
+	quoted := map[string]Post{}
+	for rows.Next() {
+		var p Post
+		var u User
+		var avatar sql.NullString
+		if err = rows.Scan(
+			&p.ID,
+			&p.Content,
+			&p.SpoilerOf,
+			&p.NSFW,
+			&p.Visibility,
+			&p.LikesCount,
+			&p.CommentsCount,
+			&p.RepostsCount,
+			&p.CreatedAt,
+			&u.Username,
+			&avatar,
+		); err != nil {
+			return fmt.Errorf("could not scan quoted post: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		p.User = &u
+		quoted[p.ID] = p
+	}
+
+	if err = rows.Err(); err != nil {
+		return fmt.Errorf("could not iterate quoted post rows: %w", err)
+	}
+
+	for _, p := range pp {
+		if p.QuotedPostID == nil {
+			continue
+		}
+
+		q, ok := quoted[*p.QuotedPostID]
+		if !ok {
+			q = Post{ID: *p.QuotedPostID, Unavailable: true}
+		}
+		p.QuotedPost = &q
+	}
+
+	return nil
+}
+
+// UpdatePost changes the content, spoiler and nsfw flag of a post owned by the authenticated user.
+// Nil fields are left unchanged and an empty spoilerOf removes the spoiler.
+// The previous version is kept as a revision.
+func (s *Service) UpdatePost(ctx context.Context, postID string, content, spoilerOf *string, nsfw *bool) (Post, error) {
+	var p Post
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return p, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(postID) {
+		return p, ErrInvalidPostID
+	}
+
+	if content != nil {
+		*content = smartTrim(*content)
+		if *content == "" || utf8.RuneCountInString(*content) > 480 {
+			return p, ErrInvalidContent
+		}
+	}
+
+	if spoilerOf != nil {
+		*spoilerOf = smartTrim(*spoilerOf)
+		if utf8.RuneCountInString(*spoilerOf) > 64 {
+			return p, ErrInvalidSpoiler
+		}
+	}
+
+	var changed bool
+	var notified []string
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		notified = nil
+
+		var userID, oldContent string
+		var oldSpoilerOf *string
+		var oldNSFW bool
+		query := "SELECT user_id, content, spoiler_of, nsfw FROM posts WHERE id = $1 FOR UPDATE"
+		err := tx.QueryRowContext(ctx, query, postID).Scan(&userID, &oldContent, &oldSpoilerOf, &oldNSFW)
+		if err == sql.ErrNoRows {
+			return ErrPostNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select post to update: %w", err)
+		}
+
+		if userID != uid {
+			return ErrPermissionDenied
+		}
+
+		newContent := oldContent
+		if content != nil {
+			newContent = *content
+		}
+
+		newSpoilerOf := oldSpoilerOf
+		if spoilerOf != nil {
+			newSpoilerOf = spoilerOf
+			if *spoilerOf == "" {
+				newSpoilerOf = nil
+			}
+		}
+
+		newNSFW := oldNSFW
+		if nsfw != nil {
+			newNSFW = *nsfw
+		}
+
+		changed = newContent != oldContent || newNSFW != oldNSFW || !equalStringPtr(newSpoilerOf, oldSpoilerOf)
+		if !changed {
+			return nil
+		}
+
+		query = "INSERT INTO post_revisions (post_id, content, spoiler_of, nsfw) VALUES ($1, $2, $3, $4)"
+		if _, err = tx.ExecContext(ctx, query, postID, oldContent, oldSpoilerOf, oldNSFW); err != nil {
+			return fmt.Errorf("could not insert post revision: %w", err)
+		}
+
+		query = "UPDATE posts SET content = $1, spoiler_of = $2, nsfw = $3 WHERE id = $4"
+		if _, err = tx.ExecContext(ctx, query, newContent, newSpoilerOf, newNSFW, postID); err != nil {
+			return fmt.Errorf("could not update post: %w", err)
+		}
+
+		// Only users never mentioned in the post before get notified,
+		// so removing a mention and adding it back doesn't notify them again.
+		added := addedMentions(oldContent, newContent)
+		if len(added) != 0 {
+			query = `
+				SELECT username FROM users
+				WHERE username = ANY($1)
+				AND NOT EXISTS (SELECT 1 FROM post_mentions WHERE post_id = $2 AND user_id = users.id)`
+			rows, err := tx.QueryContext(ctx, query, pq.Array(added), postID)
+			if err != nil {
+				return fmt.Errorf("could not query select never mentioned users: %w", err)
+			}
+
+			defer rows.Close()
+
+			for rows.Next() {
+				var username string
+				if err = rows.Scan(&username); err != nil {
+					return fmt.Errorf("could not scan never mentioned user: %w", err)
+				}
+
+				notified = append(notified, username)
+			}
+
+			if err = rows.Err(); err != nil {
+				return fmt.Errorf("could not iterate never mentioned user rows: %w", err)
+			}
+		}
+
+		// Users mentioned before keep seeing the post.
+		if err = insertPostMentions(ctx, tx, postID, uid, added); err != nil {
+			return err
+		}
+
+		if err = updateTags(ctx, tx, postID, nil, oldContent, newContent); err != nil {
+			return err
+		}
+
+		return nil
+	})
+	if err != nil {
+		return p, err
+	}
+
+	p, err = s.Post(ctx, postID)
+	if err != nil {
+		return p, err
+	}
+
+	if changed {
+		p.UserID = uid
+		go s.notifyPostMention(p, notified)
+	}
+
 	return p, nil
 }
 
+// PostRevisions of a post in descending order.
+// Only available to whoever can see the post.
+func (s *Service) PostRevisions(ctx context.Context, postID string) ([]PostRevision, error) {
+	if !reUUID.MatchString(postID) {
+		return nil, ErrInvalidPostID
+	}
+
+	if _, err := s.Post(ctx, postID); err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, content, spoiler_of, nsfw, created_at
+		FROM post_revisions
+		WHERE post_id = $1
+		ORDER BY created_at DESC`, postID)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select post revisions: %w", err)
+	}
+
+	defer rows.Close()
+
+	rr := []PostRevision{}
+	for rows.Next() {
+		var r PostRevision
+		if err = rows.Scan(&r.ID, &r.Content, &r.SpoilerOf, &r.NSFW, &r.CreatedAt); err != nil {
+			return nil, fmt.Errorf("could not scan post revision: %w", err)
+		}
+
+		r.PostID = postID
+		rr = append(rr, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate post revision rows: %w", err)
+	}
+
+	return rr, nil
+}
+
+// DeletePost owned by the authenticated user along with everything that depends on it.
+// Followers with the post on their timeline receive a deleted item through the timeline stream.
+func (s *Service) DeletePost(ctx context.Context, postID string) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(postID) {
+		return ErrInvalidPostID
+	}
+
+	var tt []TimelineItem
+	var mm []storedMedia
+	var userID string
+	var fannedOut bool
+	var pulledReposterIDs []string
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		tt = nil
+		mm = nil
+
+		query := "SELECT user_id, fanned_out FROM posts WHERE id = $1 FOR UPDATE"
+		err := tx.QueryRowContext(ctx, query, postID).Scan(&userID, &fannedOut)
+		if err == sql.ErrNoRows {
+			return ErrPostNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select post to delete: %w", err)
+		}
+
+		if userID != uid {
+			return ErrPermissionDenied
+		}
+
+		query = "SELECT user_id FROM reposts WHERE post_id = $1 AND NOT fanned_out"
+		reposterRows, err := tx.QueryContext(ctx, query, postID)
+		if err != nil {
+			return fmt.Errorf("could not query select pulled post reposters: %w", err)
+		}
+
+		if pulledReposterIDs, err = scanIDs(reposterRows); err != nil {
+			return fmt.Errorf("could not scan pulled post reposters: %w", err)
+		}
+
+		query = `
+			DELETE FROM comment_likes
+			WHERE comment_id IN (SELECT id FROM comments WHERE post_id = $1)`
+		if _, err = tx.ExecContext(ctx, query, postID); err != nil {
+			return fmt.Errorf("could not delete post comment likes: %w", err)
+		}
+
+		for _, table := range []string{
+			"tags",
+			"comments",
+			"post_likes",
+			"post_subscriptions",
+			"post_revisions",
+			"post_mentions",
+			"reposts",
+			"ranked_timeline",
+			"timeline_hides",
+			"notifications",
+		} {
+			query = fmt.Sprintf("DELETE FROM %s WHERE post_id = $1", table)
+			if _, err = tx.ExecContext(ctx, query, postID); err != nil {
+				return fmt.Errorf("could not delete post %s: %w", table, err)
+			}
+		}
+
+		query = "DELETE FROM post_media WHERE post_id = $1 RETURNING file, thumbnail"
+		mediaRows, err := tx.QueryContext(ctx, query, postID)
+		if err != nil {
+			return fmt.Errorf("could not delete post media: %w", err)
+		}
+
+		defer mediaRows.Close()
+
+		for mediaRows.Next() {
+			var m storedMedia
+			if err = mediaRows.Scan(&m.File, &m.Thumbnail); err != nil {
+				return fmt.Errorf("could not scan deleted post media: %w", err)
+			}
+
+			mm = append(mm, m)
+		}
+
+		if err = mediaRows.Err(); err != nil {
+			return fmt.Errorf("could not iterate deleted post media rows: %w", err)
+		}
+
+		query = "DELETE FROM timeline WHERE post_id = $1 RETURNING id, user_id"
+		rows, err := tx.QueryContext(ctx, query, postID)
+		if err != nil {
+			return fmt.Errorf("could not delete post timeline items: %w", err)
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			ti := TimelineItem{PostID: postID, Deleted: true}
+			if err = rows.Scan(&ti.ID, &ti.UserID); err != nil {
+				return fmt.Errorf("could not scan deleted timeline item: %w", err)
+			}
+
+			tt = append(tt, ti)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("could not iterate deleted timeline item rows: %w", err)
+		}
+
+		query = "DELETE FROM posts WHERE id = $1"
+		if _, err = tx.ExecContext(ctx, query, postID); err != nil {
+			return fmt.Errorf("could not delete post: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	go s.removeMedia(mm)
+
+	go s.broadcastTimelineItems(tt)
+
+	// Followers got it merged in on read.
+	if !fannedOut {
+		go s.broadcastToFollowers(userID, TimelineItem{ID: postID, PostID: postID, Deleted: true})
+	}
+
+	for _, reposterID := range pulledReposterIDs {
+		go s.broadcastToFollowers(reposterID, TimelineItem{ID: postID, PostID: postID, Deleted: true})
+	}
+
+	return nil
+}
+
 // TogglePostLike 🖤
 // 给帖子点赞
 func (s *Service) TogglePostLike(ctx context.Context, postID string) (ToggleLikeOutput, error) {
@@ -344,6 +957,187 @@ func (s *Service) TogglePostLike(ctx context.Context, postID string) (ToggleLike
 	return out, nil
 }
 
+// Repost shares someone else's post with the authenticated user followers.
+func (s *Service) Repost(ctx context.Context, postID string) (RepostOutput, error) {
+	var out RepostOutput
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(postID) {
+		return out, ErrInvalidPostID
+	}
+
+	var authorID string
+	var inserted bool
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		var private bool
+		var visibility string
+		query := `
+			SELECT posts.user_id, users.private, posts.visibility FROM posts
+			INNER JOIN users ON posts.user_id = users.id
+			WHERE posts.id = $1`
+		err := tx.QueryRowContext(ctx, query, postID).Scan(&authorID, &private, &visibility)
+		if err == sql.ErrNoRows {
+			return ErrPostNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select post author: %w", err)
+		}
+
+		// Posts of private accounts and non public posts are only for their audience.
+		if authorID == uid || private || visibility != VisibilityPublic {
+			return ErrForbiddenRepost
+		}
+
+		blocked, err := blockedBetween(ctx, tx, uid, authorID)
+		if err != nil {
+			return err
+		}
+
+		if blocked {
+			return ErrUserBlocked
+		}
+
+		// Same as posts, reposts from users with too many followers get merged into timelines on read.
+		query = `
+			INSERT INTO reposts (user_id, post_id, fanned_out)
+			VALUES ($1, $2, (SELECT followers_count <= $3 FROM users WHERE id = $1))
+			ON CONFLICT (user_id, post_id) DO NOTHING`
+		res, err := tx.ExecContext(ctx, query, uid, postID, fanoutThreshold)
+		if err != nil {
+			return fmt.Errorf("could not insert repost: %w", err)
+		}
+
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("could not get inserted repost count: %w", err)
+		}
+
+		inserted = n == 1
+		if inserted {
+			query = "UPDATE posts SET reposts_count = reposts_count + 1 WHERE id = $1 RETURNING reposts_count"
+		} else {
+			query = "SELECT reposts_count FROM posts WHERE id = $1"
+		}
+		if err = tx.QueryRowContext(ctx, query, postID).Scan(&out.RepostsCount); err != nil {
+			return fmt.Errorf("could not update and increment post reposts count: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return out, err
+	}
+
+	out.Reposted = true
+
+	if inserted {
+		go s.repostCreated(postID, authorID, uid)
+	}
+
+	return out, nil
+}
+
+func (s *Service) repostCreated(postID, authorID, reposterID string) {
+	ctx := context.Background()
+	reposter, err := s.userByID(ctx, reposterID)
+	if err != nil {
+		log.Printf("could not fetch reposter: %v\n", err)
+		return
+	}
+
+	p, err := s.Post(ctx, postID)
+	if err != nil {
+		log.Printf("could not fetch reposted post: %v\n", err)
+		return
+	}
+
+	p.UserID = authorID
+
+	go s.fanoutRepost(p, reposter)
+	go s.notifyRepost(p, reposter)
+}
+
+// Unrepost undoes a repost and takes it out of the authenticated user followers' timelines.
+func (s *Service) Unrepost(ctx context.Context, postID string) (RepostOutput, error) {
+	var out RepostOutput
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	if !reUUID.MatchString(postID) {
+		return out, ErrInvalidPostID
+	}
+
+	var tt []TimelineItem
+	var pulled bool
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		tt = nil
+
+		var fannedOut bool
+		query := "DELETE FROM reposts WHERE user_id = $1 AND post_id = $2 RETURNING fanned_out"
+		err := tx.QueryRowContext(ctx, query, uid, postID).Scan(&fannedOut)
+		deleted := err == nil
+		if err != nil && err != sql.ErrNoRows {
+			return fmt.Errorf("could not delete repost: %w", err)
+		}
+
+		pulled = deleted && !fannedOut
+		if deleted {
+			query = "UPDATE posts SET reposts_count = reposts_count - 1 WHERE id = $1 RETURNING reposts_count"
+		} else {
+			query = "SELECT reposts_count FROM posts WHERE id = $1"
+		}
+		err = tx.QueryRowContext(ctx, query, postID).Scan(&out.RepostsCount)
+		if err == sql.ErrNoRows {
+			return ErrPostNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not update and decrement post reposts count: %w", err)
+		}
+
+		query = "DELETE FROM timeline WHERE post_id = $1 AND reposted_by = $2 RETURNING id, user_id"
+		rows, err := tx.QueryContext(ctx, query, postID, uid)
+		if err != nil {
+			return fmt.Errorf("could not delete repost timeline items: %w", err)
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			ti := TimelineItem{PostID: postID, Deleted: true}
+			if err = rows.Scan(&ti.ID, &ti.UserID); err != nil {
+				return fmt.Errorf("could not scan deleted repost timeline item: %w", err)
+			}
+
+			tt = append(tt, ti)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("could not iterate deleted repost timeline item rows: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return out, err
+	}
+
+	go s.broadcastTimelineItems(tt)
+
+	// Followers got it merged in on read.
+	if pulled {
+		go s.broadcastToFollowers(uid, TimelineItem{ID: postID, PostID: postID, Deleted: true})
+	}
+
+	return out, nil
+}
+
 // TogglePostSubscription so you can stop receiving notifications from a thread.
 func (s *Service) TogglePostSubscription(ctx context.Context, postID string) (ToggleSubscriptionOutput, error) {
 	var out ToggleSubscriptionOutput
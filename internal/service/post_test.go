@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"testing"
+)
+
+func TestService_PostRevisions_privateAccount(t *testing.T) {
+	s := newTestService(t)
+	authorID := insertTestUser(t, s, true)
+	followerID := insertTestUser(t, s, false)
+	strangerID := insertTestUser(t, s, false)
+	postID := insertTestPost(t, s, authorID)
+
+	mustExec(t, s, "INSERT INTO follows (follower_id, followee_id) VALUES ($1, $2)", followerID, authorID)
+	t.Cleanup(func() { mustExec(t, s, "DELETE FROM follows WHERE follower_id = $1", followerID) })
+
+	for name, ctx := range map[string]context.Context{
+		"anonymous":    context.Background(),
+		"non_follower": authCtx(strangerID),
+	} {
+		t.Run(name, func(t *testing.T) {
+			if _, err := s.PostRevisions(ctx, postID); err != ErrPostNotFound {
+				t.Errorf("PostRevisions() = %v; want %v", err, ErrPostNotFound)
+			}
+		})
+	}
+
+	for name, ctx := range map[string]context.Context{
+		"author":   authCtx(authorID),
+		"follower": authCtx(followerID),
+	} {
+		t.Run(name, func(t *testing.T) {
+			rr, err := s.PostRevisions(ctx, postID)
+			if err != nil {
+				t.Fatalf("PostRevisions() = %v", err)
+			}
+
+			if len(rr) != 1 || rr[0].Content != "original" {
+				t.Errorf("PostRevisions() = %+v; want the original revision", rr)
+			}
+		})
+	}
+}
+
+func TestService_PostRevisions_blocked(t *testing.T) {
+	s := newTestService(t)
+	authorID := insertTestUser(t, s, false)
+	blockedID := insertTestUser(t, s, false)
+	postID := insertTestPost(t, s, authorID)
+
+	mustExec(t, s, "INSERT INTO blocks (blocker_id, blocked_id) VALUES ($1, $2)", authorID, blockedID)
+	t.Cleanup(func() { mustExec(t, s, "DELETE FROM blocks WHERE blocker_id = $1", authorID) })
+
+	if _, err := s.PostRevisions(authCtx(blockedID), postID); err != ErrPostNotFound {
+		t.Errorf("PostRevisions() = %v; want %v", err, ErrPostNotFound)
+	}
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/cockroachdb/cockroach-go/crdb"
+)
+
+const (
+	// rankedTimelineSize is the max number of posts ranked on each refresh.
+	rankedTimelineSize = 500
+)
+
+// trendingPosts are the most engaged recent posts,
+// candidates from outside the user network.
+// The min sum of likes, comments and reposts is inlined
+// so the partial trending_posts index applies.
+const trendingPosts = `SELECT id FROM posts
+	WHERE created_at > now() - INTERVAL '7 days'
+	AND likes_count + comments_count + reposts_count >= 10
+	ORDER BY likes_count + comments_count + reposts_count DESC
+	LIMIT @size`
+
+// RankedTimelineOutput response.
+type RankedTimelineOutput struct {
+	Items []TimelineItem `json:"items"`
+	PageInfo
+}
+
+type rankedTimelineCursor struct {
+	SnapshotID string  `json:"s"`
+	Score      float64 `json:"r"`
+	PostID     string  `json:"p"`
+}
+
+// rankedPostScore scores a candidate post for the authenticated user.
+// Followees weigh the most, then friends of friends.
+// Engagement and past interactions with the author push it up, and age decays it.
+const rankedPostScore = `(
+	(CASE
+		WHEN followees.followee_id IS NOT NULL THEN 3
+		WHEN friends_of_friends.user_id IS NOT NULL THEN 1.5
+		ELSE 1
+	END)::FLOAT8
+	* (1 + ln((1 + posts.likes_count + 2 * posts.comments_count + 3 * posts.reposts_count)::FLOAT8))
+	* (1 + least(COALESCE(interactions.interactions_count, 0), 20)::FLOAT8 / 10)
+	/ power(extract(epoch FROM now() - posts.created_at)::FLOAT8 / 3600 + 2, 1.5)
+)`
+
+// RankedTimeline of the authenticated user, "For You", with forward cursor pagination.
+// Candidates are recent posts from followees, friends of friends and trending ones.
+// The first page ranks them and takes a snapshot; the following ones read from it,
+// so scores changing in between don't repeat nor skip items.
+// Items have the post ID as their ID.
+func (s *Service) RankedTimeline(ctx context.Context, first int, after string) (RankedTimelineOutput, error) {
+	var out RankedTimelineOutput
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return out, ErrUnauthenticated
+	}
+
+	var cursor rankedTimelineCursor
+	if after != "" {
+		if err := decodeCursor(after, &cursor); err != nil ||
+			!reUUID.MatchString(cursor.SnapshotID) ||
+			!reUUID.MatchString(cursor.PostID) {
+			return out, ErrInvalidCursor
+		}
+
+		var exists bool
+		query := "SELECT EXISTS (SELECT 1 FROM ranked_timeline WHERE snapshot_id = $1 AND user_id = $2)"
+		if err := s.db.QueryRowContext(ctx, query, cursor.SnapshotID, uid).Scan(&exists); err != nil {
+			return out, fmt.Errorf("could not query select ranked timeline snapshot existence: %w", err)
+		}
+
+		// The snapshot expired. Clients should start over.
+		if !exists {
+			return out, ErrInvalidCursor
+		}
+	} else {
+		snapshotID, err := s.rankTimeline(ctx, uid)
+		if err != nil {
+			return out, err
+		}
+
+		cursor.SnapshotID = snapshotID
+	}
+
+	first = normalizePageSize(first)
+	query, args, err := buildQuery(`
+		SELECT posts.id, content, spoiler_of, nsfw, visibility, likes_count, comments_count, reposts_count, quoted_post_id, posts.created_at
+		, posts.user_id = @uid AS mine
+		, likes.user_id IS NOT NULL AS liked
+		, subscriptions.user_id IS NOT NULL AS subscribed
+		, reposts.user_id IS NOT NULL AS reposted
+		, users.username, users.avatar
+		, ranked_timeline.score
+		FROM ranked_timeline
+		INNER JOIN posts ON ranked_timeline.post_id = posts.id
+		INNER JOIN users ON posts.user_id = users.id
+		LEFT JOIN post_likes AS likes
+			ON likes.user_id = @uid AND likes.post_id = posts.id
+		LEFT JOIN post_subscriptions AS subscriptions
+			ON subscriptions.user_id = @uid AND subscriptions.post_id = posts.id
+		LEFT JOIN reposts
+			ON reposts.user_id = @uid AND reposts.post_id = posts.id
+		WHERE ranked_timeline.snapshot_id = @snapshotID
+		AND ranked_timeline.user_id = @uid
+		{{if .cursor}}AND (ranked_timeline.score, ranked_timeline.post_id) < (@score::FLOAT8, @postID::UUID){{end}}
+		AND `+rankedTimelineFilter+`
+		ORDER BY ranked_timeline.score DESC, ranked_timeline.post_id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"auth":       true,
+		"uid":        uid,
+		"snapshotID": cursor.SnapshotID,
+		"cursor":     after != "",
+		"score":      cursor.Score,
+		"postID":     cursor.PostID,
+		"limit":      first + 1,
+	})
+	if err != nil {
+		return out, fmt.Errorf("could not build ranked timeline sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return out, fmt.Errorf("could not query select ranked timeline: %w", err)
+	}
+
+	defer rows.Close()
+
+	tt := make([]TimelineItem, 0, first+1)
+	scores := make([]float64, 0, first+1)
+	for rows.Next() {
+		var p Post
+		var u User
+		var avatar sql.NullString
+		var score float64
+		if err = rows.Scan(
+			&p.ID,
+			&p.Content,
+			&p.SpoilerOf,
+			&p.NSFW,
+			&p.Visibility,
+			&p.LikesCount,
+			&p.CommentsCount,
+			&p.RepostsCount,
+			&p.QuotedPostID,
+			&p.CreatedAt,
+			&p.Mine,
+			&p.Liked,
+			&p.Subscribed,
+			&p.Reposted,
+			&u.Username,
+			&avatar,
+			&score,
+		); err != nil {
+			return out, fmt.Errorf("could not scan ranked timeline item: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		p.User = &u
+		tt = append(tt, TimelineItem{ID: p.ID, UserID: uid, PostID: p.ID, Post: &p})
+		scores = append(scores, score)
+	}
+
+	if err = rows.Err(); err != nil {
+		return out, fmt.Errorf("could not iterate ranked timeline rows: %w", err)
+	}
+
+	if len(tt) > first {
+		tt = tt[:first]
+		out.PageInfo = nextPage(rankedTimelineCursor{
+			SnapshotID: cursor.SnapshotID,
+			Score:      scores[first-1],
+			PostID:     tt[first-1].PostID,
+		})
+	}
+
+	pp := make([]*Post, len(tt))
+	for i := range tt {
+		pp[i] = tt[i].Post
+	}
+	if err = s.attachPostDetails(ctx, pp...); err != nil {
+		return out, err
+	}
+
+	out.Items = tt
+	return out, nil
+}
+
+// rankedTimelineFilter leaves out the posts the user shouldn't see.
+// Checked both when ranking and when reading, since blocks, mutes
+// and follows can change while going through a snapshot.
+const rankedTimelineFilter = `NOT EXISTS (
+		SELECT 1 FROM blocks
+		WHERE (blocker_id = posts.user_id AND blocked_id = @uid)
+			OR (blocker_id = @uid AND blocked_id = posts.user_id)
+	)
+	AND NOT EXISTS (
+		SELECT 1 FROM mutes
+		WHERE mutes.user_id = @uid AND ` + activeMute + `
+			AND (mutes.muted_user_id = posts.user_id OR lower(posts.content) ~ ` + mutedWordPattern + `)
+	)
+	AND (NOT users.private OR users.id = @uid OR EXISTS (
+		SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = users.id
+	))
+	AND ` + visiblePost
+
+// rankTimeline scores the candidate posts for the given user
+// and stores them as a new snapshot replacing the previous ones from the user.
+func (s *Service) rankTimeline(ctx context.Context, uid string) (string, error) {
+	var snapshotID string
+	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		query := "DELETE FROM ranked_timeline WHERE user_id = $1"
+		if _, err := tx.ExecContext(ctx, query, uid); err != nil {
+			return fmt.Errorf("could not delete previous ranked timeline snapshots: %w", err)
+		}
+
+		if err := tx.QueryRowContext(ctx, "SELECT gen_random_uuid()").Scan(&snapshotID); err != nil {
+			return fmt.Errorf("could not generate ranked timeline snapshot id: %w", err)
+		}
+
+		query, args, err := buildQuery(`
+			INSERT INTO ranked_timeline (snapshot_id, user_id, post_id, score)
+			SELECT @snapshotID, @uid, candidates.id, candidates.score FROM (
+				SELECT posts.id, `+rankedPostScore+` AS score
+				FROM posts
+				INNER JOIN users ON posts.user_id = users.id
+				LEFT JOIN follows AS followees
+					ON followees.follower_id = @uid AND followees.followee_id = posts.user_id
+				LEFT JOIN (
+					SELECT DISTINCT indirect.followee_id AS user_id FROM follows AS direct
+					INNER JOIN follows AS indirect ON indirect.follower_id = direct.followee_id
+					WHERE direct.follower_id = @uid
+				) AS friends_of_friends ON friends_of_friends.user_id = posts.user_id
+				LEFT JOIN (`+userInteractions+`) AS interactions ON interactions.user_id = posts.user_id
+				LEFT JOIN (`+trendingPosts+`) AS trending ON trending.id = posts.id
+				WHERE posts.created_at > now() - INTERVAL '7 days'
+				AND posts.user_id != @uid
+				AND (
+					followees.followee_id IS NOT NULL
+					OR friends_of_friends.user_id IS NOT NULL
+					OR trending.id IS NOT NULL
+				)
+				AND `+rankedTimelineFilter+`
+				ORDER BY score DESC
+				LIMIT @size
+			) AS candidates`, map[string]interface{}{
+			"auth":       true,
+			"uid":        uid,
+			"snapshotID": snapshotID,
+			"size":       rankedTimelineSize,
+		})
+		if err != nil {
+			return fmt.Errorf("could not build rank timeline sql query: %w", err)
+		}
+
+		if _, err = tx.ExecContext(ctx, query, args...); err != nil {
+			return fmt.Errorf("could not insert ranked timeline snapshot: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return snapshotID, nil
+}
@@ -0,0 +1,406 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/lib/pq"
+)
+
+// maxSearchQueryLength is the max number of characters of a search query.
+const maxSearchQueryLength = 200
+
+var (
+	reSearchToken = regexp.MustCompile(`"([^"]*)"?|(\S+)`)
+	reSearchWord  = regexp.MustCompile(`[\p{L}\p{N}]+`)
+)
+
+var (
+	// ErrInvalidSearchQuery denotes an empty or too long search query,
+	// or one with invalid operators.
+	ErrInvalidSearchQuery = errors.New("invalid search query")
+)
+
+// SearchHit is either a post or a comment matching a search.
+type SearchHit struct {
+	Type    string   `json:"type"` // "post" or "comment"
+	PostID  string   `json:"postID"`
+	Post    *Post    `json:"post,omitempty"`
+	Comment *Comment `json:"comment,omitempty"`
+	cursor  searchCursor
+}
+
+// SearchPostsOutput response.
+type SearchPostsOutput struct {
+	Hits []SearchHit `json:"hits"`
+	PageInfo
+}
+
+type searchCursor struct {
+	Rank      float64   `json:"r"`
+	CreatedAt time.Time `json:"c"`
+	ID        string    `json:"i"`
+}
+
+// searchQuery is a parsed search.
+// tsquery is the to_tsquery input out of the words and quoted phrases.
+type searchQuery struct {
+	tsquery string
+	from    string
+	tags    []string
+}
+
+// SearchPosts ranks the posts and comments matching the given query by relevance,
+// with cursor pagination. Quoted text matches as a phrase;
+// "from:username" matches the author and "#tag" the hashtags used.
+// Results follow the same visibility rules as everywhere else.
+func (s *Service) SearchPosts(ctx context.Context, q string, first int, after string) (SearchPostsOutput, error) {
+	var out SearchPostsOutput
+	sq, err := parseSearchQuery(q)
+	if err != nil {
+		return out, err
+	}
+
+	var cursor *searchCursor
+	if after != "" {
+		var c searchCursor
+		if err := decodeCursor(after, &c); err != nil || !reUUID.MatchString(c.ID) {
+			return out, ErrInvalidCursor
+		}
+
+		cursor = &c
+	}
+
+	first = normalizePageSize(first)
+	// One extra hit from each source tells whether there is a next page.
+	pp, err := s.searchPostHits(ctx, sq, cursor, first+1)
+	if err != nil {
+		return out, err
+	}
+
+	cc, err := s.searchCommentHits(ctx, sq, cursor, first+1)
+	if err != nil {
+		return out, err
+	}
+
+	hits := append(pp, cc...)
+	sort.Slice(hits, func(i, j int) bool {
+		return searchCursorLess(hits[j].cursor, hits[i].cursor)
+	})
+
+	if len(hits) > first {
+		hits = hits[:first]
+		out.PageInfo = nextPage(hits[first-1].cursor)
+	}
+
+	posts := []*Post{}
+	for i := range hits {
+		if hits[i].Post != nil {
+			posts = append(posts, hits[i].Post)
+		}
+	}
+	if err = s.attachPostDetails(ctx, posts...); err != nil {
+		return out, err
+	}
+
+	out.Hits = hits
+	return out, nil
+}
+
+func (s *Service) searchPostHits(ctx context.Context, sq searchQuery, cursor *searchCursor, limit int) ([]SearchHit, error) {
+	uid, auth := ctx.Value(KeyAuthUserID).(string)
+	data := searchQueryData(sq, cursor, limit)
+	data["auth"] = auth
+	data["uid"] = uid
+	query, args, err := buildQuery(`
+		SELECT * FROM (
+			SELECT posts.id, content, spoiler_of, nsfw, visibility, likes_count, comments_count, reposts_count, quoted_post_id, posts.created_at
+			, users.username, users.avatar
+			{{if .auth}}
+			, posts.user_id = @uid AS mine
+			, likes.user_id IS NOT NULL AS liked
+			, subscriptions.user_id IS NOT NULL AS subscribed
+			, reposts.user_id IS NOT NULL AS reposted
+			{{end}}
+			, {{if .tsquery}}ts_rank(posts.search_vector, to_tsquery('english', @tsquery))::FLOAT8{{else}}0::FLOAT8{{end}} AS rank
+			FROM posts
+			INNER JOIN users ON posts.user_id = users.id
+			{{if .auth}}
+			LEFT JOIN post_likes AS likes
+				ON likes.user_id = @uid AND likes.post_id = posts.id
+			LEFT JOIN post_subscriptions AS subscriptions
+				ON subscriptions.user_id = @uid AND subscriptions.post_id = posts.id
+			LEFT JOIN reposts
+				ON reposts.user_id = @uid AND reposts.post_id = posts.id
+			{{end}}
+			WHERE true
+			{{if .tsquery}}AND posts.search_vector @@ to_tsquery('english', @tsquery){{end}}
+			{{if .from}}AND users.username = @from{{end}}
+			{{if .hashtags}}AND (
+				SELECT count(DISTINCT tags.tag) FROM tags
+				WHERE tags.post_id = posts.id AND tags.comment_id IS NULL AND tags.tag = ANY(@hashtags)
+			) = @tagsCount{{end}}
+			{{if .auth}}
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks
+				WHERE (blocker_id = posts.user_id AND blocked_id = @uid)
+					OR (blocker_id = @uid AND blocked_id = posts.user_id)
+			)
+			{{end}}
+			AND (NOT users.private{{if .auth}} OR users.id = @uid OR EXISTS (
+				SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = users.id
+			){{end}})
+			AND `+visiblePost+`
+		) AS hits
+		{{if .cursor}}WHERE (hits.rank, hits.created_at, hits.id) < (@rank::FLOAT8, @createdAt::TIMESTAMPTZ, @id::UUID){{end}}
+		ORDER BY hits.rank DESC, hits.created_at DESC, hits.id DESC
+		LIMIT @limit`, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not build search posts sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select post search hits: %w", err)
+	}
+
+	defer rows.Close()
+
+	hits := make([]SearchHit, 0, limit)
+	for rows.Next() {
+		var p Post
+		var u User
+		var avatar sql.NullString
+		var rank float64
+		dest := []interface{}{
+			&p.ID,
+			&p.Content,
+			&p.SpoilerOf,
+			&p.NSFW,
+			&p.Visibility,
+			&p.LikesCount,
+			&p.CommentsCount,
+			&p.RepostsCount,
+			&p.QuotedPostID,
+			&p.CreatedAt,
+			&u.Username,
+			&avatar,
+		}
+		if auth {
+			dest = append(dest, &p.Mine, &p.Liked, &p.Subscribed, &p.Reposted)
+		}
+		dest = append(dest, &rank)
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan post search hit: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		p.User = &u
+		hits = append(hits, SearchHit{
+			Type:   "post",
+			PostID: p.ID,
+			Post:   &p,
+			cursor: searchCursor{Rank: rank, CreatedAt: p.CreatedAt, ID: p.ID},
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate post search hit rows: %w", err)
+	}
+
+	return hits, nil
+}
+
+func (s *Service) searchCommentHits(ctx context.Context, sq searchQuery, cursor *searchCursor, limit int) ([]SearchHit, error) {
+	uid, auth := ctx.Value(KeyAuthUserID).(string)
+	data := searchQueryData(sq, cursor, limit)
+	data["auth"] = auth
+	data["uid"] = uid
+	query, args, err := buildQuery(`
+		SELECT * FROM (
+			SELECT comments.id, comments.post_id, comments.parent_id, comments.content
+			, comments.likes_count, comments.replies_count, comments.created_at
+			, users.username, users.avatar
+			{{if .auth}}
+			, comments.user_id = @uid AS mine
+			, likes.user_id IS NOT NULL AS liked
+			{{end}}
+			, {{if .tsquery}}ts_rank(comments.search_vector, to_tsquery('english', @tsquery))::FLOAT8{{else}}0::FLOAT8{{end}} AS rank
+			FROM comments
+			INNER JOIN users ON comments.user_id = users.id
+			INNER JOIN posts ON comments.post_id = posts.id
+			INNER JOIN users AS owners ON posts.user_id = owners.id
+			{{if .auth}}
+			LEFT JOIN comment_likes AS likes
+				ON likes.comment_id = comments.id AND likes.user_id = @uid
+			{{end}}
+			WHERE true
+			{{if .tsquery}}AND comments.search_vector @@ to_tsquery('english', @tsquery){{end}}
+			{{if .from}}AND users.username = @from{{end}}
+			{{if .hashtags}}AND (
+				SELECT count(DISTINCT tags.tag) FROM tags
+				WHERE tags.comment_id = comments.id AND tags.tag = ANY(@hashtags)
+			) = @tagsCount{{end}}
+			{{if .auth}}
+			AND NOT EXISTS (
+				SELECT 1 FROM blocks
+				WHERE (blocker_id IN (comments.user_id, posts.user_id) AND blocked_id = @uid)
+					OR (blocker_id = @uid AND blocked_id IN (comments.user_id, posts.user_id))
+			)
+			{{end}}
+			AND (NOT owners.private{{if .auth}} OR owners.id = @uid OR EXISTS (
+				SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = owners.id
+			){{end}})
+			AND `+visiblePost+`
+		) AS hits
+		{{if .cursor}}WHERE (hits.rank, hits.created_at, hits.id) < (@rank::FLOAT8, @createdAt::TIMESTAMPTZ, @id::UUID){{end}}
+		ORDER BY hits.rank DESC, hits.created_at DESC, hits.id DESC
+		LIMIT @limit`, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not build search comments sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select comment search hits: %w", err)
+	}
+
+	defer rows.Close()
+
+	hits := make([]SearchHit, 0, limit)
+	for rows.Next() {
+		var c Comment
+		var u User
+		var avatar sql.NullString
+		var rank float64
+		dest := []interface{}{
+			&c.ID,
+			&c.PostID,
+			&c.ParentID,
+			&c.Content,
+			&c.LikesCount,
+			&c.RepliesCount,
+			&c.CreatedAt,
+			&u.Username,
+			&avatar,
+		}
+		if auth {
+			dest = append(dest, &c.Mine, &c.Liked)
+		}
+		dest = append(dest, &rank)
+
+		if err = rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("could not scan comment search hit: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		c.User = &u
+		hits = append(hits, SearchHit{
+			Type:    "comment",
+			PostID:  c.PostID,
+			Comment: &c,
+			cursor:  searchCursor{Rank: rank, CreatedAt: c.CreatedAt, ID: c.ID},
+		})
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate comment search hit rows: %w", err)
+	}
+
+	return hits, nil
+}
+
+func searchQueryData(sq searchQuery, cursor *searchCursor, limit int) map[string]interface{} {
+	data := map[string]interface{}{
+		"tsquery": sq.tsquery,
+		"from":    sq.from,
+		"limit":   limit,
+	}
+	if len(sq.tags) != 0 {
+		data["hashtags"] = pq.Array(sq.tags)
+		data["tagsCount"] = len(sq.tags)
+	}
+	if cursor != nil {
+		data["cursor"] = true
+		data["rank"] = cursor.Rank
+		data["createdAt"] = cursor.CreatedAt
+		data["id"] = cursor.ID
+	}
+	return data
+}
+
+// parseSearchQuery out of words, "quoted phrases", from:username and #tag operators.
+// Words and phrases must all match.
+func parseSearchQuery(q string) (searchQuery, error) {
+	var sq searchQuery
+	q = strings.TrimSpace(q)
+	if q == "" || utf8.RuneCountInString(q) > maxSearchQueryLength {
+		return sq, ErrInvalidSearchQuery
+	}
+
+	terms := []string{}
+	seenTags := map[string]struct{}{}
+	for _, submatch := range reSearchToken.FindAllStringSubmatch(q, -1) {
+		phrase, token := submatch[1], submatch[2]
+		if token == "" {
+			if words := reSearchWord.FindAllString(strings.ToLower(phrase), -1); len(words) != 0 {
+				terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+			}
+			continue
+		}
+
+		if strings.HasPrefix(token, "from:") {
+			if sq.from != "" || !reUsername.MatchString(token[len("from:"):]) {
+				return sq, ErrInvalidSearchQuery
+			}
+
+			sq.from = token[len("from:"):]
+			continue
+		}
+
+		if strings.HasPrefix(token, "#") {
+			tag := strings.ToLower(token[1:])
+			if !reTag.MatchString(tag) {
+				return sq, ErrInvalidSearchQuery
+			}
+
+			if _, ok := seenTags[tag]; !ok {
+				seenTags[tag] = struct{}{}
+				sq.tags = append(sq.tags, tag)
+			}
+			continue
+		}
+
+		// Words joined by punctuation, like e-mail, match as a phrase too.
+		if words := reSearchWord.FindAllString(strings.ToLower(token), -1); len(words) != 0 {
+			terms = append(terms, "("+strings.Join(words, " <-> ")+")")
+		}
+	}
+
+	if len(terms) == 0 && sq.from == "" && len(sq.tags) == 0 {
+		return sq, ErrInvalidSearchQuery
+	}
+
+	sq.tsquery = strings.Join(terms, " & ")
+	return sq, nil
+}
+
+func searchCursorLess(a, b searchCursor) bool {
+	if a.Rank != b.Rank {
+		return a.Rank < b.Rank
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.Before(b.CreatedAt)
+	}
+	return a.ID < b.ID
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func Test_parseSearchQuery(t *testing.T) {
+	tt := []struct {
+		name    string
+		q       string
+		want    searchQuery
+		wantErr error
+	}{
+		{
+			name:    "empty",
+			q:       "   ",
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "too_long",
+			q:       strings.Repeat("a", maxSearchQueryLength+1),
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name: "words",
+			q:    "Hello World",
+			want: searchQuery{tsquery: "(hello) & (world)"},
+		},
+		{
+			name: "phrase",
+			q:    `"hello world" again`,
+			want: searchQuery{tsquery: "(hello <-> world) & (again)"},
+		},
+		{
+			name: "unclosed_phrase",
+			q:    `"hello world`,
+			want: searchQuery{tsquery: "(hello <-> world)"},
+		},
+		{
+			name: "punctuated_word",
+			q:    "e-mail",
+			want: searchQuery{tsquery: "(e <-> mail)"},
+		},
+		{
+			name: "from",
+			q:    "from:john hello",
+			want: searchQuery{tsquery: "(hello)", from: "john"},
+		},
+		{
+			name:    "from_twice",
+			q:       "from:john from:jane",
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "from_invalid_username",
+			q:       "from:-",
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name: "tags",
+			q:    "#Go #rust",
+			want: searchQuery{tags: []string{"go", "rust"}},
+		},
+		{
+			name: "duplicated_tags",
+			q:    "#go #Go #GO",
+			want: searchQuery{tags: []string{"go"}},
+		},
+		{
+			name:    "invalid_tag",
+			q:       "#1abc",
+			wantErr: ErrInvalidSearchQuery,
+		},
+		{
+			name:    "only_punctuation",
+			q:       `!!! ""`,
+			wantErr: ErrInvalidSearchQuery,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := parseSearchQuery(tc.q)
+			if err != tc.wantErr {
+				t.Fatalf("want error %v; got %v", tc.wantErr, err)
+			}
+
+			if tc.wantErr != nil {
+				return
+			}
+
+			if !reflect.DeepEqual(tc.want, got) {
+				t.Errorf("want %+v; got %+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -1,11 +1,14 @@
 package service
 
 import (
+	"context"
 	"database/sql"
+	"log"
 	"net/url"
 
 	"github.com/nicolasparada/nakama/internal/mailing"
 	"github.com/nicolasparada/nakama/internal/pubsub"
+	"github.com/nicolasparada/nakama/internal/storage"
 )
 
 // Service contains the core business logic separated from the transport layer.
@@ -17,6 +20,8 @@ type Service struct {
 	templateDir string
 	tokenKey    string
 	pubsub      pubsub.PubSub
+	store       storage.Store
+	broadcasts  chan struct{} // bounds the timeline items being published at the same time
 }
 
 // Conf contains all service configuration.
@@ -27,6 +32,7 @@ type Conf struct {
 	TemplateDir string
 	TokenKey    string
 	PubSub      pubsub.PubSub
+	Store       storage.Store
 }
 
 // New service implementation.
@@ -38,9 +44,21 @@ func New(conf Conf) *Service {
 		templateDir: conf.TemplateDir,
 		tokenKey:    conf.TokenKey,
 		pubsub:      conf.PubSub,
+		store:       conf.Store,
+		broadcasts:  make(chan struct{}, broadcastConcurrency),
 	}
 
 	go s.deleteExpiredVerificationCodesJob()
+	go s.resumeAccountDeletions()
+	go s.backfillAvatarVariants()
 
 	return s
 }
+
+// deleteBlob from the store logging any error.
+// Meant to be run in the background once the blob is no longer referenced.
+func (s *Service) deleteBlob(name string) {
+	if err := s.store.Delete(context.Background(), name); err != nil {
+		log.Printf("could not delete blob %q: %v\n", name, err)
+	}
+}
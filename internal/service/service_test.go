@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"net/url"
+	"os"
+	"testing"
+
+	"github.com/nicolasparada/nakama/internal/storage/disk"
+)
+
+// newTestService runs against a CockroachDB with schema.sql loaded:
+//
+//	cockroach start-single-node --insecure
+//	cat schema.sql | cockroach sql --insecure
+//
+// Set TEST_DATABASE_URL to run the tests needing it.
+// Each test inserts its own rows and deletes them after.
+func newTestService(t *testing.T) *Service {
+	t.Helper()
+
+	dbURL := os.Getenv("TEST_DATABASE_URL")
+	if dbURL == "" {
+		t.Skip("TEST_DATABASE_URL not set")
+	}
+
+	db, err := sql.Open("postgres", dbURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { db.Close() })
+
+	origin := &url.URL{Scheme: "http", Host: "localhost:3000"}
+	return &Service{
+		db:         db,
+		origin:     origin,
+		store:      &disk.Store{Dir: t.TempDir(), BaseURL: origin},
+		broadcasts: make(chan struct{}, broadcastConcurrency),
+	}
+}
+
+// insertTestUser with a random username and returns its id.
+func insertTestUser(t *testing.T, s *Service, private bool) string {
+	t.Helper()
+
+	b := make([]byte, 6)
+	if _, err := rand.Read(b); err != nil {
+		t.Fatal(err)
+	}
+
+	username := "test_" + hex.EncodeToString(b)
+	var id string
+	query := "INSERT INTO users (email, username, private) VALUES ($1, $2, $3) RETURNING id"
+	if err := s.db.QueryRow(query, username+"@example.org", username, private).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { mustExec(t, s, "DELETE FROM users WHERE id = $1", id) })
+	return id
+}
+
+// insertTestPost by the given user with one revision and returns its id.
+func insertTestPost(t *testing.T, s *Service, userID string) string {
+	t.Helper()
+
+	var id string
+	query := "INSERT INTO posts (user_id, content) VALUES ($1, 'edited') RETURNING id"
+	if err := s.db.QueryRow(query, userID).Scan(&id); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Cleanup(func() { mustExec(t, s, "DELETE FROM posts WHERE id = $1", id) })
+
+	mustExec(t, s, "INSERT INTO post_revisions (post_id, content, nsfw) VALUES ($1, 'original', false)", id)
+	t.Cleanup(func() { mustExec(t, s, "DELETE FROM post_revisions WHERE post_id = $1", id) })
+	return id
+}
+
+func mustExec(t *testing.T, s *Service, query string, args ...interface{}) {
+	t.Helper()
+
+	if _, err := s.db.Exec(query, args...); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func authCtx(uid string) context.Context {
+	return context.WithValue(context.Background(), KeyAuthUserID, uid)
+}
@@ -0,0 +1,273 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// DefaultTrendingWindow is the time window used to rank trending tags by default.
+	DefaultTrendingWindow = time.Hour * 24
+	// maxTrendingWindow is the longest time window to rank trending tags.
+	maxTrendingWindow = time.Hour * 24 * 30
+)
+
+var reTag = regexp.MustCompile(`^\p{L}[\p{L}\p{N}_]{0,63}$`)
+
+var (
+	// ErrInvalidTag denotes an invalid hashtag.
+	ErrInvalidTag = errors.New("invalid tag")
+	// ErrInvalidTrendingWindow denotes a not positive or too long trending tags time window.
+	ErrInvalidTrendingWindow = errors.New("invalid trending window")
+)
+
+// TrendingTag model.
+type TrendingTag struct {
+	Tag        string `json:"tag"`
+	UsesCount  int    `json:"usesCount"`
+	PostsCount int    `json:"postsCount"`
+}
+
+// TagPosts are the public posts using the given hashtag
+// in descending order and with backward pagination.
+// Hashtags used only in comments don't count.
+func (s *Service) TagPosts(ctx context.Context, tag string, last int, before string) (PostsOutput, error) {
+	var out PostsOutput
+	tag = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(tag), "#"))
+	if !reTag.MatchString(tag) {
+		return out, ErrInvalidTag
+	}
+
+	var cursor timeCursor
+	if before != "" {
+		var err error
+		if cursor, err = decodeTimeCursor(before); err != nil {
+			return out, err
+		}
+	}
+
+	uid, auth := ctx.Value(KeyAuthUserID).(string)
+	last = normalizePageSize(last)
+	query, args, err := buildQuery(`
+		SELECT posts.id, content, spoiler_of, nsfw, visibility, likes_count, comments_count, reposts_count, quoted_post_id, posts.created_at
+		, users.username, users.avatar
+		{{if .auth}}
+		, posts.user_id = @uid AS mine
+		, likes.user_id IS NOT NULL AS liked
+		, subscriptions.user_id IS NOT NULL AS subscribed
+		, reposts.user_id IS NOT NULL AS reposted
+		{{end}}
+		FROM posts
+		INNER JOIN users ON posts.user_id = users.id
+		{{if .auth}}
+		LEFT JOIN post_likes AS likes
+			ON likes.user_id = @uid AND likes.post_id = posts.id
+		LEFT JOIN post_subscriptions AS subscriptions
+			ON subscriptions.user_id = @uid AND subscriptions.post_id = posts.id
+		LEFT JOIN reposts
+			ON reposts.user_id = @uid AND reposts.post_id = posts.id
+		{{end}}
+		WHERE EXISTS (
+			SELECT 1 FROM tags WHERE tags.post_id = posts.id AND tags.comment_id IS NULL AND tags.tag = @tag
+		)
+		{{if .auth}}
+		AND NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = posts.user_id AND blocked_id = @uid)
+				OR (blocker_id = @uid AND blocked_id = posts.user_id)
+		)
+		{{end}}
+		AND (NOT users.private{{if .auth}} OR users.id = @uid OR EXISTS (
+			SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = users.id
+		){{end}})
+		AND `+visiblePost+`
+		{{if .before}}AND (posts.created_at, posts.id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+		ORDER BY posts.created_at DESC, posts.id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"auth":       auth,
+		"uid":        uid,
+		"tag":        tag,
+		"limit":      last + 1,
+		"before":     before != "",
+		"cursorTime": cursor.Time,
+		"cursorID":   cursor.ID,
+	})
+	if err != nil {
+		return out, fmt.Errorf("could not build tag posts sql query: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return out, fmt.Errorf("could not query select tag posts: %w", err)
+	}
+
+	defer rows.Close()
+
+	pp := make([]Post, 0, last+1)
+	for rows.Next() {
+		var p Post
+		var u User
+		var avatar sql.NullString
+		dest := []interface{}{
+			&p.ID,
+			&p.Content,
+			&p.SpoilerOf,
+			&p.NSFW,
+			&p.Visibility,
+			&p.LikesCount,
+			&p.CommentsCount,
+			&p.RepostsCount,
+			&p.QuotedPostID,
+			&p.CreatedAt,
+			&u.Username,
+			&avatar,
+		}
+		if auth {
+			dest = append(dest, &p.Mine, &p.Liked, &p.Subscribed, &p.Reposted)
+		}
+
+		if err = rows.Scan(dest...); err != nil {
+			return out, fmt.Errorf("could not scan tag post: %w", err)
+		}
+
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
+		p.User = &u
+		pp = append(pp, p)
+	}
+
+	if err = rows.Err(); err != nil {
+		return out, fmt.Errorf("could not iterate tag post rows: %w", err)
+	}
+
+	if len(pp) > last {
+		pp = pp[:last]
+		out.PageInfo = nextPage(timeCursor{Time: pp[last-1].CreatedAt, ID: pp[last-1].ID})
+	}
+
+	tagged := make([]*Post, len(pp))
+	for i := range pp {
+		tagged[i] = &pp[i]
+	}
+	if err = s.attachPostDetails(ctx, tagged...); err != nil {
+		return out, err
+	}
+
+	out.Items = pp
+	return out, nil
+}
+
+// TrendingTags are the most used hashtags within the given time window
+// counting both posts and comments. Only public posts from public accounts count.
+func (s *Service) TrendingTags(ctx context.Context, window time.Duration, first int) ([]TrendingTag, error) {
+	if window == 0 {
+		window = DefaultTrendingWindow
+	}
+
+	if window < 0 || window > maxTrendingWindow {
+		return nil, ErrInvalidTrendingWindow
+	}
+
+	first = normalizePageSize(first)
+	query := `
+		SELECT tags.tag, count(*) AS uses_count, count(DISTINCT tags.post_id) AS posts_count
+		FROM tags
+		INNER JOIN posts ON tags.post_id = posts.id
+		INNER JOIN users ON posts.user_id = users.id
+		WHERE tags.created_at > $1
+			AND posts.visibility = 'public'
+			AND NOT users.private
+		GROUP BY tags.tag
+		ORDER BY uses_count DESC, posts_count DESC, tags.tag ASC
+		LIMIT $2`
+	rows, err := s.db.QueryContext(ctx, query, time.Now().Add(-window), first)
+	if err != nil {
+		return nil, fmt.Errorf("could not query select trending tags: %w", err)
+	}
+
+	defer rows.Close()
+
+	tt := make([]TrendingTag, 0, first)
+	for rows.Next() {
+		var t TrendingTag
+		if err = rows.Scan(&t.Tag, &t.UsesCount, &t.PostsCount); err != nil {
+			return nil, fmt.Errorf("could not scan trending tag: %w", err)
+		}
+
+		tt = append(tt, t)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("could not iterate trending tag rows: %w", err)
+	}
+
+	return tt, nil
+}
+
+// insertTags records the hashtags used in a post, or in a comment when commentID is given.
+func insertTags(ctx context.Context, tx *sql.Tx, postID string, commentID *string, tags []string) error {
+	if len(tags) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO tags (tag, post_id, comment_id)
+		SELECT unnest($1::VARCHAR[]), $2, $3`
+	if _, err := tx.ExecContext(ctx, query, pq.Array(tags), postID, commentID); err != nil {
+		return fmt.Errorf("could not insert tags: %w", err)
+	}
+
+	return nil
+}
+
+// updateTags of a post, or of a comment when commentID is given, after editing its content.
+// Tags kept across the edit don't get their usage time renewed.
+func updateTags(ctx context.Context, tx *sql.Tx, postID string, commentID *string, oldContent, newContent string) error {
+	added, removed := changedTags(oldContent, newContent)
+	if len(removed) != 0 {
+		query := `
+			DELETE FROM tags
+			WHERE post_id = $1 AND comment_id IS NOT DISTINCT FROM $2::UUID AND tag = ANY($3)`
+		if _, err := tx.ExecContext(ctx, query, postID, commentID, pq.Array(removed)); err != nil {
+			return fmt.Errorf("could not delete removed tags: %w", err)
+		}
+	}
+
+	return insertTags(ctx, tx, postID, commentID, added)
+}
+
+// changedTags returns the tags present in the new content but not in the old one, and vice versa.
+func changedTags(oldContent, newContent string) (added, removed []string) {
+	oldTags := collectTags(oldContent)
+	newTags := collectTags(newContent)
+
+	old := map[string]struct{}{}
+	for _, t := range oldTags {
+		old[t] = struct{}{}
+	}
+
+	kept := map[string]struct{}{}
+	for _, t := range newTags {
+		if _, ok := old[t]; ok {
+			kept[t] = struct{}{}
+			continue
+		}
+
+		added = append(added, t)
+	}
+
+	for _, t := range oldTags {
+		if _, ok := kept[t]; !ok {
+			removed = append(removed, t)
+		}
+	}
+
+	return added, removed
+}
@@ -9,6 +9,20 @@ import (
 	"fmt"
 	"io"
 	"log"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// fanoutThreshold is the followers count above which posts don't get fanned out on write.
+	// Followers get them merged into their timelines on read instead.
+	fanoutThreshold = 10000
+	// fanoutBatchSize is the number of followers to insert timeline items for at once.
+	fanoutBatchSize = 500
+	// broadcastConcurrency is the max number of timeline items being published at the same time.
+	broadcastConcurrency = 32
 )
 
 // ErrInvalidTimelineItemID denotes an invalid timeline item id; that is not uuid.
@@ -16,92 +30,195 @@ var ErrInvalidTimelineItemID = errors.New("invalid timeline item id")
 
 // TimelineItem model.
 type TimelineItem struct {
-	ID     string `json:"id"`
-	UserID string `json:"-"`
-	PostID string `json:"-"`
-	Post   *Post  `json:"post,omitempty"`
+	ID         string `json:"id"`
+	UserID     string `json:"-"`
+	PostID     string `json:"-"`
+	Post       *Post  `json:"post,omitempty"`
+	RepostedBy *User  `json:"repostedBy,omitempty"`
+	Deleted    bool   `json:"deleted,omitempty"` // set on stream events telling the client to drop the item
+}
+
+// TimelineOutput response.
+type TimelineOutput struct {
+	Items []TimelineItem `json:"items"`
+	PageInfo
 }
 
 // Timeline of the authenticated user in descending order and with backward pagination.
+// before is the cursor from a previous page.
+// Posts and reposts from followees not fanned out on write get merged in, unless hidden;
+// those items have the post ID as their ID. A post reposted by several followees
+// shows once, as its first repost, same as with fanned out ones.
 // 将帖子按发布时间排序进行返回
-func (s *Service) Timeline(ctx context.Context, last int, before string) ([]TimelineItem, error) {
+func (s *Service) Timeline(ctx context.Context, last int, before string) (TimelineOutput, error) {
+	var out TimelineOutput
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
-		return nil, ErrUnauthenticated
+		return out, ErrUnauthenticated
+	}
+
+	var cursor timeCursor
+	if before != "" {
+		var err error
+		if cursor, err = decodeTimeCursor(before); err != nil {
+			return out, err
+		}
 	}
 
 	last = normalizePageSize(last)
 	// 按创建时间递减进行排序，这样最新创建的帖子就在最前面
 	query, args, err := buildQuery(`
-		SELECT timeline.id, posts.id, content, spoiler_of, nsfw, likes_count, comments_count, created_at
+		SELECT items.id, items.created_at, posts.id, content, spoiler_of, nsfw, visibility, likes_count, comments_count, reposts_count, quoted_post_id, posts.created_at
 		, posts.user_id = @uid AS mine
 		, likes.user_id IS NOT NULL AS liked
 		, subscriptions.user_id IS NOT NULL AS subscribed
+		, reposts.user_id IS NOT NULL AS reposted
 		, users.username, users.avatar
-		FROM timeline
-		INNER JOIN posts ON timeline.post_id = posts.id
+		, reposters.username, reposters.avatar
+		FROM (
+			SELECT id, created_at, post_id, reposted_by FROM timeline
+			WHERE user_id = @uid
+			{{if .before}}AND (created_at, id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+			UNION ALL
+			SELECT id, created_at, post_id, reposted_by FROM (
+				SELECT DISTINCT ON (pulled.post_id) pulled.post_id AS id, pulled.created_at, pulled.post_id, pulled.reposted_by
+				FROM (
+					SELECT posts.id AS post_id, posts.created_at, NULL::UUID AS reposted_by FROM posts
+					INNER JOIN follows ON follows.follower_id = @uid AND follows.followee_id = posts.user_id
+					WHERE NOT posts.fanned_out
+					UNION ALL
+					SELECT reposts.post_id, reposts.created_at, reposts.user_id FROM reposts
+					INNER JOIN follows ON follows.follower_id = @uid AND follows.followee_id = reposts.user_id
+					WHERE NOT reposts.fanned_out
+				) AS pulled
+				WHERE NOT EXISTS (
+					SELECT 1 FROM timeline WHERE timeline.user_id = @uid AND timeline.post_id = pulled.post_id
+				)
+				AND NOT EXISTS (
+					SELECT 1 FROM timeline_hides WHERE timeline_hides.user_id = @uid AND timeline_hides.post_id = pulled.post_id
+				)
+				ORDER BY pulled.post_id, pulled.created_at
+			) AS pulled_items
+			{{if .before}}WHERE (created_at, id) < (@cursorTime::TIMESTAMPTZ, @cursorID::UUID){{end}}
+		) AS items
+		INNER JOIN posts ON items.post_id = posts.id
 		INNER JOIN users ON posts.user_id = users.id
+		LEFT JOIN users AS reposters ON items.reposted_by = reposters.id
 		LEFT JOIN post_likes AS likes
 			ON likes.user_id = @uid AND likes.post_id = posts.id
 		LEFT JOIN post_subscriptions AS subscriptions
 			ON subscriptions.user_id = @uid AND subscriptions.post_id = posts.id
-		WHERE timeline.user_id = @uid
-		{{if .before}}AND timeline.id < @before{{end}}
-		ORDER BY created_at DESC
-		LIMIT @last`, map[string]interface{}{
-		"uid":    uid,
-		"last":   last,
-		"before": before,
+		LEFT JOIN reposts
+			ON reposts.user_id = @uid AND reposts.post_id = posts.id
+		WHERE NOT EXISTS (
+			SELECT 1 FROM blocks
+			WHERE (blocker_id = posts.user_id AND blocked_id = @uid)
+				OR (blocker_id = @uid AND blocked_id = posts.user_id)
+		)
+		AND NOT EXISTS (
+			SELECT 1 FROM mutes
+			WHERE mutes.user_id = @uid AND `+activeMute+`
+				AND (mutes.muted_user_id = posts.user_id
+					OR mutes.muted_user_id = items.reposted_by
+					OR lower(posts.content) ~ `+mutedWordPattern+`)
+		)
+		AND (NOT users.private OR users.id = @uid OR EXISTS (
+			SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = users.id
+		))
+		AND `+visiblePost+`
+		ORDER BY items.created_at DESC, items.id DESC
+		LIMIT @limit`, map[string]interface{}{
+		"auth":       true,
+		"uid":        uid,
+		"limit":      last + 1,
+		"before":     before != "",
+		"cursorTime": cursor.Time,
+		"cursorID":   cursor.ID,
 	})
 	if err != nil {
-		return nil, fmt.Errorf("could not build timeline sql query: %w", err)
+		return out, fmt.Errorf("could not build timeline sql query: %w", err)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select timeline: %w", err)
+		return out, fmt.Errorf("could not query select timeline: %w", err)
 	}
 
 	defer rows.Close()
 
-	tt := make([]TimelineItem, 0, last)
+	tt := make([]TimelineItem, 0, last+1)
+	times := make([]time.Time, 0, last+1)
 	for rows.Next() {
 		var ti TimelineItem
+		var t time.Time
 		var p Post
 		var u User
 		var avatar sql.NullString
+		var reposterUsername, reposterAvatar sql.NullString
 		if err = rows.Scan(
 			&ti.ID,
+			&t,
 			&p.ID,
 			&p.Content,
 			&p.SpoilerOf,
 			&p.NSFW,
+			&p.Visibility,
 			&p.LikesCount,
 			&p.CommentsCount,
+			&p.RepostsCount,
+			&p.QuotedPostID,
 			&p.CreatedAt,
 			&p.Mine,
 			&p.Liked,
 			&p.Subscribed,
+			&p.Reposted,
 			&u.Username,
 			&avatar,
+			&reposterUsername,
+			&reposterAvatar,
 		); err != nil {
-			return nil, fmt.Errorf("could not scan timeline item: %w", err)
+			return out, fmt.Errorf("could not scan timeline item: %w", err)
+		}
+
+		if reposterUsername.Valid {
+			ti.RepostedBy = &User{
+				Username:   reposterUsername.String,
+				AvatarURL:  s.avatarURL(reposterUsername.String, reposterAvatar),
+				AvatarURLs: s.avatarURLs(reposterUsername.String, reposterAvatar),
+			}
 		}
 
-		u.AvatarURL = s.avatarURL(avatar)
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
 		p.User = &u
 		ti.Post = &p
 		tt = append(tt, ti)
+		times = append(times, t)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate timeline rows: %w", err)
+		return out, fmt.Errorf("could not iterate timeline rows: %w", err)
 	}
 
-	return tt, nil
+	if len(tt) > last {
+		tt = tt[:last]
+		out.PageInfo = nextPage(timeCursor{Time: times[last-1], ID: tt[last-1].ID})
+	}
+
+	quoting := make([]*Post, len(tt))
+	for i := range tt {
+		quoting[i] = tt[i].Post
+	}
+	if err = s.attachPostDetails(ctx, quoting...); err != nil {
+		return out, err
+	}
+
+	out.Items = tt
+	return out, nil
 }
 
 // TimelineItemStream to receive timeline items in realtime.
+// Items from muted users or with muted words are skipped.
 // 实时接收 timelineitem，并进行消费。
 func (s *Service) TimelineItemStream(ctx context.Context) (<-chan TimelineItem, error) {
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
@@ -121,6 +238,16 @@ func (s *Service) TimelineItemStream(ctx context.Context) (<-chan TimelineItem,
 				return
 			}
 
+			muted, err := s.mutedTimelineItem(ctx, uid, ti)
+			if err != nil {
+				log.Printf("could not check timeline item mute: %v\n", err)
+				return
+			}
+
+			if muted {
+				return
+			}
+
 			tt <- ti
 		}(bytes.NewReader(data))
 	})
@@ -142,6 +269,7 @@ func (s *Service) TimelineItemStream(ctx context.Context) (<-chan TimelineItem,
 }
 
 // DeleteTimelineItem from the auth user timeline.
+// Items merged in on read get hidden instead.
 func (s *Service) DeleteTimelineItem(ctx context.Context, timelineItemID string) error {
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
@@ -152,58 +280,230 @@ func (s *Service) DeleteTimelineItem(ctx context.Context, timelineItemID string)
 		return ErrInvalidTimelineItemID
 	}
 
-	if _, err := s.db.ExecContext(ctx, `
+	result, err := s.db.ExecContext(ctx, `
 		DELETE FROM timeline
-		WHERE id = $1 AND user_id = $2`, timelineItemID, uid); err != nil {
+		WHERE id = $1 AND user_id = $2`, timelineItemID, uid)
+	if err != nil {
 		return fmt.Errorf("could not delete timeline item: %w", err)
 	}
 
+	if n, _ := result.RowsAffected(); n != 0 {
+		return nil
+	}
+
+	// Not stored; could be a post merged in on read, which has the post ID as its ID.
+	if _, err = s.db.ExecContext(ctx, `
+		INSERT INTO timeline_hides (user_id, post_id)
+		SELECT $1, posts.id FROM posts
+		WHERE posts.id = $2 AND (
+			(NOT posts.fanned_out AND EXISTS (
+				SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = posts.user_id
+			))
+			OR EXISTS (
+				SELECT 1 FROM reposts
+				INNER JOIN follows ON follows.follower_id = $1 AND follows.followee_id = reposts.user_id
+				WHERE reposts.post_id = posts.id AND NOT reposts.fanned_out
+			)
+		)
+		ON CONFLICT DO NOTHING`, uid, timelineItemID); err != nil {
+		return fmt.Errorf("could not insert timeline hide: %w", err)
+	}
+
 	return nil
 }
 
 // 更新 timeline 表，这个表的用处是什么现在还不知道
 // 关于fanout的含义，参考: https://mp.weixin.qq.com/s?__biz=MjM5NzQ3ODAwMQ==&mid=404465806&idx=1&sn=3a68a786138538ffc452bca06a4892c8&scene=0#rd
 // fanout表示广播模式，当用户发布新帖子的时候，需要通知所有关注这个用户的粉丝
+// Posts from users with more than fanoutThreshold followers are only broadcasted;
+// Timeline merges them on read.
 func (s *Service) fanoutPost(p Post) {
-	// 首先插入 timeline 表，这个表记录了userid,post_id，并且生成了一个 id字段。这个表的作用是用来给关注的用户进行通知用的
-	// 所有关注的用户会订阅 timelineTopic() 生成的topic，然后发了新帖子的时候，会给这个 topic 生产一个消息，然后供
-	// 订阅这个 topic 的用户去进行消费。
-	// INSERT INTO 语句后加上 SELECT 语句，表示这条INSERT语句中的value里的个别值需要从SELECT 查询的表中获取。
-	// 这里的SQL 语句含义是：从follows 中查出followee_id=p.UserID 的记录中的 follower_id ，再加上p.ID 作为 INSERT 语句的value。
-	// 这里有点疑惑的是：p.ID（也就是postid）根本不是 follows 表中的字段，假设 p.ID = 112233445566，那么久变成了：
-	// SELECT follower_id, 112233445566 FROM follows WHERE followee_id = $2，SELECT 语句后跟一个常量而不是一个字段名，这样的方式是百分百能够返回 follower_id 的值加上112233445566吗？
-	// 如果是这样的话，这两个值刚好组成 (user_id, post_id) 的值，可以插入到 timeline 表中。
-	// 所以，这个sql 的意思是：将所有关注 p.UserID 的用户，以及这个postid 组成一行数据插入到 timeline 表中，然后返回插入timeline表后自动生成的 id 和 user_id，
-	// 返回的每一条结果代表一条需要通知的消息。
-	query := `
-		INSERT INTO timeline (user_id, post_id)
-		SELECT follower_id, $1 FROM follows WHERE followee_id = $2
-		RETURNING id, user_id`
-	rows, err := s.db.Query(query, p.ID, p.UserID)
+	// 仅提及可见的帖子只推送给被@的用户
+	if p.Visibility == VisibilityMentioned {
+		query := `
+			INSERT INTO timeline (user_id, post_id)
+			SELECT user_id, $1 FROM post_mentions WHERE post_id = $1 AND user_id != $2
+			ON CONFLICT (user_id, post_id) DO NOTHING
+			RETURNING id, user_id`
+		rows, err := s.db.Query(query, p.ID, p.UserID)
+		if err != nil {
+			log.Printf("could not insert timeline: %v\n", err)
+			return
+		}
+
+		tt, err := scanTimelineItems(rows, p, nil)
+		if err != nil {
+			log.Printf("could not scan timeline items: %v\n", err)
+			return
+		}
+
+		s.broadcastTimelineItems(tt)
+		return
+	}
+
+	var fannedOut bool
+	if err := s.db.QueryRow("SELECT fanned_out FROM posts WHERE id = $1", p.ID).Scan(&fannedOut); err != nil {
+		log.Printf("could not query select post fanned out: %v\n", err)
+		return
+	}
+
+	if !fannedOut {
+		s.broadcastToFollowers(p.UserID, TimelineItem{ID: p.ID, PostID: p.ID, Post: &p})
+		return
+	}
+
+	s.fanoutToFollowers(p, p.UserID, nil)
+}
+
+// fanoutRepost pushes a reposted post into the reposter followers' timelines.
+// Followers that already have the post in their timeline are skipped.
+// Reposts from users with more than fanoutThreshold followers are only broadcasted;
+// Timeline merges them on read.
+func (s *Service) fanoutRepost(p Post, reposter User) {
+	var fannedOut bool
+	query := "SELECT fanned_out FROM reposts WHERE user_id = $1 AND post_id = $2"
+	err := s.db.QueryRow(query, reposter.ID, p.ID).Scan(&fannedOut)
+	// Already undone.
+	if err == sql.ErrNoRows {
+		return
+	}
+
 	if err != nil {
-		log.Printf("could not insert timeline: %v\n", err)
+		log.Printf("could not query select repost fanned out: %v\n", err)
+		return
+	}
+
+	if !fannedOut {
+		s.broadcastToFollowers(reposter.ID, TimelineItem{ID: p.ID, PostID: p.ID, Post: &p, RepostedBy: &reposter})
 		return
 	}
 
+	s.fanoutToFollowers(p, reposter.ID, &reposter)
+}
+
+// fanoutToFollowers inserts the post into the timelines of the given user followers
+// in batches of fanoutBatchSize, and broadcasts each batch before going with the next one.
+// 按照 follower_id 分批插入 timeline 表，避免一次性插入太多行。
+func (s *Service) fanoutToFollowers(p Post, followeeID string, reposter *User) {
+	var repostedBy *string
+	if reposter != nil {
+		repostedBy = &reposter.ID
+	}
+
+	err := s.eachFollowersBatch(followeeID, func(followerIDs []string) error {
+		query := `
+			INSERT INTO timeline (user_id, post_id, reposted_by)
+			SELECT unnest($1::UUID[]), $2, $3
+			ON CONFLICT (user_id, post_id) DO NOTHING
+			RETURNING id, user_id`
+		rows, err := s.db.Query(query, pq.Array(followerIDs), p.ID, repostedBy)
+		if err != nil {
+			return fmt.Errorf("could not insert timeline: %w", err)
+		}
+
+		tt, err := scanTimelineItems(rows, p, reposter)
+		if err != nil {
+			return fmt.Errorf("could not scan timeline items: %w", err)
+		}
+
+		//通知所有关注这个用户的粉丝，这些放到后台通知，对粉丝的通知没必要等待，尽快响应。
+		s.broadcastTimelineItems(tt)
+		return nil
+	})
+	if err != nil {
+		log.Printf("could not fanout to followers: %v\n", err)
+	}
+}
+
+// broadcastToFollowers publishes a copy of the given item to each of the user followers,
+// without storing it. Used for posts merged in on read.
+func (s *Service) broadcastToFollowers(followeeID string, ti TimelineItem) {
+	err := s.eachFollowersBatch(followeeID, func(followerIDs []string) error {
+		tt := make([]TimelineItem, len(followerIDs))
+		for i, followerID := range followerIDs {
+			tt[i] = ti
+			tt[i].UserID = followerID
+		}
+
+		s.broadcastTimelineItems(tt)
+		return nil
+	})
+	if err != nil {
+		log.Printf("could not broadcast to followers: %v\n", err)
+	}
+}
+
+// eachFollowersBatch calls fn with the given user followers in batches of fanoutBatchSize
+// ordered by id, stopping at the first error.
+func (s *Service) eachFollowersBatch(followeeID string, fn func(followerIDs []string) error) error {
+	var after *string
+	for {
+		query := `
+			SELECT follower_id FROM follows
+			WHERE followee_id = $1 AND ($2::UUID IS NULL OR follower_id > $2)
+			ORDER BY follower_id
+			LIMIT $3`
+		rows, err := s.db.Query(query, followeeID, after, fanoutBatchSize)
+		if err != nil {
+			return fmt.Errorf("could not query select followers: %w", err)
+		}
+
+		followerIDs, err := scanIDs(rows)
+		if err != nil {
+			return fmt.Errorf("could not scan followers: %w", err)
+		}
+
+		if len(followerIDs) == 0 {
+			return nil
+		}
+
+		if err = fn(followerIDs); err != nil {
+			return err
+		}
+
+		if len(followerIDs) < fanoutBatchSize {
+			return nil
+		}
+
+		after = &followerIDs[len(followerIDs)-1]
+	}
+}
+
+// scanTimelineItems out of rows with the id and user_id of the inserted items, and closes them.
+func scanTimelineItems(rows *sql.Rows, p Post, reposter *User) ([]TimelineItem, error) {
 	defer rows.Close()
 
+	var tt []TimelineItem
 	for rows.Next() {
 		var ti TimelineItem
-		if err = rows.Scan(&ti.ID, &ti.UserID); err != nil {
-			log.Printf("could not scan timeline item: %v\n", err)
-			return
+		if err := rows.Scan(&ti.ID, &ti.UserID); err != nil {
+			return nil, err
 		}
 
 		ti.PostID = p.ID
 		ti.Post = &p
-
-		go s.broadcastTimelineItem(ti) //通知所有关注这个用户的粉丝，这些放到后台通知，对粉丝的通知没必要等待，尽快响应。
+		ti.RepostedBy = reposter
+		tt = append(tt, ti)
 	}
 
-	if err = rows.Err(); err != nil {
-		log.Printf("could not iterate timeline rows: %v\n", err)
-		return
+	return tt, rows.Err()
+}
+
+// scanIDs out of rows with a single id column, and closes them.
+func scanIDs(rows *sql.Rows) ([]string, error) {
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+
+		ids = append(ids, id)
 	}
+
+	return ids, rows.Err()
 }
 
 //广播一条 TimelineItem，一条 TimelineItem 表示一个通知。
@@ -223,5 +523,25 @@ func (s *Service) broadcastTimelineItem(ti TimelineItem) {
 	}
 }
 
+// broadcastTimelineItems publishes the given items and waits for them.
+// At most broadcastConcurrency items are being published at the same time across the service.
+func (s *Service) broadcastTimelineItems(tt []TimelineItem) {
+	var wg sync.WaitGroup
+	for _, ti := range tt {
+		s.broadcasts <- struct{}{}
+		wg.Add(1)
+		go func(ti TimelineItem) {
+			defer func() {
+				<-s.broadcasts
+				wg.Done()
+			}()
+
+			s.broadcastTimelineItem(ti)
+		}(ti)
+	}
+
+	wg.Wait()
+}
+
 // 对于关注userID 这个用户的所有粉丝进行通知的消息的topic 命名为 "timeline_item_" + userID
 func timelineTopic(userID string) string { return "timeline_item_" + userID }
@@ -1,31 +1,36 @@
 package service
 
 import (
+	"bytes"
 	"context"
 	"database/sql"
 	"errors"
 	"fmt"
 	"image"
-	"image/jpeg"
-	"image/png"
 	"io"
-	"os"
-	"path"
+	"io/ioutil"
+	"net/url"
 	"regexp"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/cockroachdb/cockroach-go/crdb"
-	"github.com/disintegration/imaging"
-	gonanoid "github.com/matoous/go-nanoid"
+	"github.com/lib/pq"
 )
 
 // MaxAvatarBytes to read.
 const MaxAvatarBytes = 5 << 20 // 5MB
 
+const (
+	maxDisplayNameLength = 50
+	maxBioLength         = 160
+	maxLocationLength    = 30
+	maxWebsiteLength     = 100
+)
+
 var (
 	reEmail    = regexp.MustCompile(`^[^\s@]+@[^\s@]+\.[^\s@]+$`)
 	reUsername = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9_-]{0,17}$`)
-	avatarsDir = path.Join("web", "static", "img", "avatars")
 )
 
 var (
@@ -45,29 +50,77 @@ var (
 	ErrForbiddenFollow = errors.New("forbidden follow")
 	// ErrUnsupportedAvatarFormat denotes an unsupported avatar image format.
 	ErrUnsupportedAvatarFormat = errors.New("unsupported avatar format")
+	// ErrInvalidDisplayName denotes a too long display name.
+	ErrInvalidDisplayName = errors.New("invalid display name")
+	// ErrInvalidBio denotes a too long bio.
+	ErrInvalidBio = errors.New("invalid bio")
+	// ErrInvalidLocation denotes a too long location.
+	ErrInvalidLocation = errors.New("invalid location")
+	// ErrInvalidWebsite denotes a website that is not an http(s) URL or that is too long.
+	ErrInvalidWebsite = errors.New("invalid website")
 )
 
 // User model.
 type User struct {
-	ID        string  `json:"id,omitempty"`
-	Username  string  `json:"username"`
-	AvatarURL *string `json:"avatarURL"`
+	ID        string `json:"id,omitempty"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatarURL"`
+	// AvatarURLs by size in pixels.
+	AvatarURLs map[int]string `json:"avatarURLs"`
 }
 
 // UserProfile model.
 type UserProfile struct {
 	User
-	Email          string `json:"email,omitempty"`
-	FollowersCount int    `json:"followersCount"`
-	FolloweesCount int    `json:"followeesCount"`
-	Me             bool   `json:"me"`
-	Following      bool   `json:"following"`
-	Followeed      bool   `json:"followeed"`
+	Email          string  `json:"email,omitempty"`
+	FollowersCount int     `json:"followersCount"`
+	FolloweesCount int     `json:"followeesCount"`
+	DisplayName    *string `json:"displayName"`
+	Bio            *string `json:"bio"`
+	Location       *string `json:"location"`
+	Website        *string `json:"website"`
+	Private        bool    `json:"private"` // only approved followers can see their content
+	Me             bool    `json:"me"`
+	Following      bool    `json:"following"`
+	Followeed      bool    `json:"followeed"`
+	Requested      bool    `json:"requested"` // the authenticated user has a pending follow request to them
+	Blocked        bool    `json:"blocked"`
+}
+
+// UsersOutput response.
+type UsersOutput struct {
+	Items []UserProfile `json:"items"`
+	PageInfo
+}
+
+// UsernamesOutput response.
+type UsernamesOutput struct {
+	Items []string `json:"items"`
+	PageInfo
+}
+
+// userCursor points to a user of a list sorted by username,
+// or ranked by score and then by username on a search.
+type userCursor struct {
+	Score    float64 `json:"s"`
+	Username string  `json:"u"`
+}
+
+// decodeUserCursor out of a userCursor encoded with encodeCursor.
+func decodeUserCursor(s string) (userCursor, error) {
+	var c userCursor
+	if err := decodeCursor(s, &c); err != nil || !reUsername.MatchString(c.Username) {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
 }
 
 // ToggleFollowOutput response.
+// Following a private account makes a follow request instead.
 type ToggleFollowOutput struct {
 	Following      bool `json:"following"`
+	Requested      bool `json:"requested"`
 	FollowersCount int  `json:"followersCount"`
 }
 
@@ -102,14 +155,34 @@ func (s *Service) CreateUser(ctx context.Context, email, username string) error
 	return nil
 }
 
-// Users in ascending order with forward pagination and filtered by username.
-func (s *Service) Users(ctx context.Context, search string, first int, after string) ([]UserProfile, error) {
+// Users in ascending order with forward pagination.
+// With a search they get ranked instead, matching by username or display name.
+// after is the cursor from a previous page.
+func (s *Service) Users(ctx context.Context, search string, first int, after string) (UsersOutput, error) {
+	var out UsersOutput
+	var cursor userCursor
+	if after != "" {
+		var err error
+		if cursor, err = decodeUserCursor(after); err != nil {
+			return out, err
+		}
+	}
+
 	search = strings.TrimSpace(search)
 	first = normalizePageSize(first)
-	after = strings.TrimSpace(after)
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
-	query, args, err := buildQuery(`
+	data := map[string]interface{}{
+		"auth":           auth,
+		"uid":            uid,
+		"first":          first + 1,
+		"after":          after != "",
+		"cursorScore":    cursor.Score,
+		"cursorUsername": cursor.Username,
+	}
+	text := `
 		SELECT id, email, username, avatar, followers_count, followees_count
+		, display_name, bio, location, website
+		, 0::FLOAT8 AS score
 		{{if .auth}}
 		, followers.follower_id IS NOT NULL AS following
 		, followees.followee_id IS NOT NULL AS followeed
@@ -121,44 +194,74 @@ func (s *Service) Users(ctx context.Context, search string, first int, after str
 		LEFT JOIN follows AS followees
 			ON followees.follower_id = users.id AND followees.followee_id = @uid
 		{{end}}
-		{{if or .search .after}}WHERE{{end}}
-		{{if .search}}username ILIKE '%' || @search || '%'{{end}}
-		{{if and .search .after}}AND{{end}}
-		{{if .after}}username > @after{{end}}
+		{{if .after}}WHERE username > @cursorUsername{{end}}
 		ORDER BY username ASC
-		LIMIT @first`, map[string]interface{}{
-		"auth":   auth,
-		"uid":    uid,
-		"search": search,
-		"first":  first,
-		"after":  after,
-	})
+		LIMIT @first`
+	if search != "" {
+		userSearchData(search, data)
+		text = `
+		WITH matches AS (
+			SELECT users.id, users.email, users.username, users.avatar, users.followers_count, users.followees_count
+			, users.display_name, users.bio, users.location, users.website
+			{{if .auth}}
+			, followers.follower_id IS NOT NULL AS following
+			, followees.followee_id IS NOT NULL AS followeed
+			{{end}}
+			, ` + userSearchScore + ` AS score
+			FROM users
+			` + userSearchJoins + `
+			{{if .auth}}
+			LEFT JOIN follows AS followees
+				ON followees.follower_id = users.id AND followees.followee_id = @uid
+			{{end}}
+			WHERE ` + userSearchMatch + `
+		)
+		SELECT id, email, username, avatar, followers_count, followees_count
+		, display_name, bio, location, website
+		, score
+		{{if .auth}}, following, followeed{{end}}
+		FROM matches
+		{{if .after}}
+		WHERE score < @cursorScore::FLOAT8
+			OR (score = @cursorScore::FLOAT8 AND username > @cursorUsername)
+		{{end}}
+		ORDER BY score DESC, username ASC
+		LIMIT @first`
+	}
+	query, args, err := buildQuery(text, data)
 	if err != nil {
-		return nil, fmt.Errorf("could not build users sql query: %w", err)
+		return out, fmt.Errorf("could not build users sql query: %w", err)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select users: %w", err)
+		return out, fmt.Errorf("could not query select users: %w", err)
 	}
 
 	defer rows.Close()
-	uu := make([]UserProfile, 0, first)
+	uu := make([]UserProfile, 0, first+1)
+	scores := make([]float64, 0, first+1)
 	for rows.Next() {
 		var u UserProfile
 		var avatar sql.NullString
+		var score float64
 		dest := []interface{}{
 			&u.ID, &u.Email,
 			&u.Username,
 			&avatar,
 			&u.FollowersCount,
 			&u.FolloweesCount,
+			&u.DisplayName,
+			&u.Bio,
+			&u.Location,
+			&u.Website,
+			&score,
 		}
 		if auth {
 			dest = append(dest, &u.Following, &u.Followeed)
 		}
 		if err = rows.Scan(dest...); err != nil {
-			return nil, fmt.Errorf("could not scan user: %w", err)
+			return out, fmt.Errorf("could not scan user: %w", err)
 		}
 
 		u.Me = auth && uid == u.ID
@@ -166,65 +269,104 @@ func (s *Service) Users(ctx context.Context, search string, first int, after str
 			u.ID = ""
 			u.Email = ""
 		}
-		u.AvatarURL = s.avatarURL(avatar)
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
 		uu = append(uu, u)
+		scores = append(scores, score)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate user rows: %w", err)
+		return out, fmt.Errorf("could not iterate user rows: %w", err)
 	}
 
-	return uu, nil
+	if len(uu) > first {
+		uu = uu[:first]
+		out.PageInfo = nextPage(userCursor{Score: scores[first-1], Username: uu[first-1].Username})
+	}
+
+	out.Items = uu
+	return out, nil
 }
 
 // Usernames to autocomplete a mention box or something.
-func (s *Service) Usernames(ctx context.Context, startingWith string, first int, after string) ([]string, error) {
+// They come ranked like a Users search, with the authenticated user's followees first.
+// after is the cursor from a previous page.
+func (s *Service) Usernames(ctx context.Context, startingWith string, first int, after string) (UsernamesOutput, error) {
+	out := UsernamesOutput{Items: []string{}}
 	startingWith = strings.TrimSpace(startingWith)
 	if startingWith == "" {
-		return []string{}, nil
+		return out, nil
+	}
+
+	var cursor userCursor
+	if after != "" {
+		var err error
+		if cursor, err = decodeUserCursor(after); err != nil {
+			return out, err
+		}
 	}
 
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	first = normalizePageSize(first)
+	data := map[string]interface{}{
+		"auth":           auth,
+		"uid":            uid,
+		"after":          after != "",
+		"cursorScore":    cursor.Score,
+		"cursorUsername": cursor.Username,
+		"first":          first + 1,
+	}
+	userSearchData(startingWith, data)
 	query, args, err := buildQuery(`
-		SELECT username FROM users
-		WHERE username ILIKE @startingWith || '%'
-		{{if .auth}}AND users.id != @uid{{end}}
-		{{if .after}}AND username > @after{{end}}
-		ORDER BY username ASC
-		LIMIT @first`, map[string]interface{}{
-		"startingWith": startingWith,
-		"auth":         auth,
-		"uid":          uid,
-		"after":        after,
-		"first":        first,
-	})
+		WITH matches AS (
+			SELECT users.username, `+userSearchScore+` AS score
+			FROM users
+			`+userSearchJoins+`
+			WHERE `+userSearchMatch+`
+			{{if .auth}}AND users.id != @uid{{end}}
+		)
+		SELECT username, score FROM matches
+		{{if .after}}
+		WHERE score < @cursorScore::FLOAT8
+			OR (score = @cursorScore::FLOAT8 AND username > @cursorUsername)
+		{{end}}
+		ORDER BY score DESC, username ASC
+		LIMIT @first`, data)
 	if err != nil {
-		return nil, fmt.Errorf("could not build usernames sql query: %w", err)
+		return out, fmt.Errorf("could not build usernames sql query: %w", err)
 	}
 
 	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return nil, fmt.Errorf("could not query select usernames: %w", err)
+		return out, fmt.Errorf("could not query select usernames: %w", err)
 	}
 
 	defer rows.Close()
 
-	uu := make([]string, 0, first)
+	uu := make([]string, 0, first+1)
+	scores := make([]float64, 0, first+1)
 	for rows.Next() {
 		var u string
-		if err = rows.Scan(&u); err != nil {
-			return nil, fmt.Errorf("could not scan username: %w", err)
+		var score float64
+		if err = rows.Scan(&u, &score); err != nil {
+			return out, fmt.Errorf("could not scan username: %w", err)
 		}
 
 		uu = append(uu, u)
+		scores = append(scores, score)
 	}
 
 	if err = rows.Err(); err != nil {
-		return nil, fmt.Errorf("could not iterate username rows: %w", err)
+		return out, fmt.Errorf("could not iterate username rows: %w", err)
 	}
 
-	return uu, nil
+	if len(uu) > first {
+		uu = uu[:first]
+		out.PageInfo = nextPage(userCursor{Score: scores[first-1], Username: uu[first-1]})
+	}
+
+	out.Items = uu
+	return out, nil
 }
 
 func (s *Service) userByID(ctx context.Context, id string) (User, error) {
@@ -241,7 +383,8 @@ func (s *Service) userByID(ctx context.Context, id string) (User, error) {
 	}
 
 	u.ID = id
-	u.AvatarURL = s.avatarURL(avatar)
+	u.AvatarURL = s.avatarURL(u.Username, avatar)
+	u.AvatarURLs = s.avatarURLs(u.Username, avatar)
 
 	return u, nil
 }
@@ -261,9 +404,12 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	query, args, err := buildQuery(`
 		SELECT id, email, avatar, followers_count, followees_count
+		, display_name, bio, location, website, private
 		{{if .auth}}
 		, followers.follower_id IS NOT NULL AS following
 		, followees.followee_id IS NOT NULL AS followeed
+		, requests.follower_id IS NOT NULL AS requested
+		, blocks.blocker_id IS NOT NULL AS blocked
 		{{end}}
 		FROM users
 		{{if .auth}}
@@ -271,6 +417,10 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 			ON followers.follower_id = @uid AND followers.followee_id = users.id
 		LEFT JOIN follows AS followees
 			ON followees.follower_id = users.id AND followees.followee_id = @uid
+		LEFT JOIN follow_requests AS requests
+			ON requests.follower_id = @uid AND requests.followee_id = users.id
+		LEFT JOIN blocks
+			ON blocks.blocker_id = @uid AND blocks.blocked_id = users.id
 		{{end}}
 		WHERE username = @username`, map[string]interface{}{
 		"auth":     auth,
@@ -282,9 +432,20 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 	}
 
 	var avatar sql.NullString
-	dest := []interface{}{&u.ID, &u.Email, &avatar, &u.FollowersCount, &u.FolloweesCount}
+	dest := []interface{}{
+		&u.ID,
+		&u.Email,
+		&avatar,
+		&u.FollowersCount,
+		&u.FolloweesCount,
+		&u.DisplayName,
+		&u.Bio,
+		&u.Location,
+		&u.Website,
+		&u.Private,
+	}
 	if auth {
-		dest = append(dest, &u.Following, &u.Followeed)
+		dest = append(dest, &u.Following, &u.Followeed, &u.Requested, &u.Blocked)
 	}
 	err = s.db.QueryRowContext(ctx, query, args...).Scan(dest...)
 	if err == sql.ErrNoRows {
@@ -301,80 +462,313 @@ func (s *Service) User(ctx context.Context, username string) (UserProfile, error
 		u.ID = ""
 		u.Email = ""
 	}
-	u.AvatarURL = s.avatarURL(avatar)
+	u.AvatarURL = s.avatarURL(u.Username, avatar)
+	u.AvatarURLs = s.avatarURLs(u.Username, avatar)
 	return u, nil
 }
 
-// UpdateAvatar of the authenticated user returning the new avatar URL.
-// 这里更新的头像只是将其生成了一个随机的ID作为头像文件名称，然后创建这个文件，将上传的图片经过转换后保存到这个文件中，
-// PS: 这个文件只是保存在本地，实际商用肯定要改成分布式文件系统或者云平台
-func (s *Service) UpdateAvatar(ctx context.Context, r io.Reader) (string, error) {
+// ChangeUsername of the authenticated user.
+// The old username is kept in a history so it can still be resolved
+// and notification actors get migrated to the new one.
+func (s *Service) ChangeUsername(ctx context.Context, username string) error {
 	uid, ok := ctx.Value(KeyAuthUserID).(string)
 	if !ok {
-		return "", ErrUnauthenticated
+		return ErrUnauthenticated
 	}
 
-	r = io.LimitReader(r, MaxAvatarBytes)
-	img, format, err := image.Decode(r) // 将上传的二进制数据解码成Image对象
-	if err == image.ErrFormat {
-		return "", ErrUnsupportedAvatarFormat
+	username = strings.TrimSpace(username)
+	if !reUsername.MatchString(username) {
+		return ErrInvalidUsername
+	}
+
+	return crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		var oldUsername string
+		query := "SELECT username FROM users WHERE id = $1 FOR UPDATE"
+		err := tx.QueryRowContext(ctx, query, uid).Scan(&oldUsername)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not query select username: %w", err)
+		}
+
+		if oldUsername == username {
+			return nil
+		}
+
+		query = "UPDATE users SET username = $1 WHERE id = $2"
+		_, err = tx.ExecContext(ctx, query, username, uid)
+		if isUniqueViolation(err) {
+			return ErrUsernameTaken
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not update username: %w", err)
+		}
+
+		// The new username is no longer an old handle of anyone.
+		query = "DELETE FROM username_history WHERE username = $1"
+		if _, err = tx.ExecContext(ctx, query, username); err != nil {
+			return fmt.Errorf("could not delete username history: %w", err)
+		}
+
+		query = "UPSERT INTO username_history (username, user_id, changed_at) VALUES ($1, $2, now())"
+		if _, err = tx.ExecContext(ctx, query, oldUsername, uid); err != nil {
+			return fmt.Errorf("could not insert username history: %w", err)
+		}
+
+		query = `
+			UPDATE notifications SET actors = array_replace(actors, $1, $2)
+			WHERE $1 = ANY(actors)`
+		if _, err = tx.ExecContext(ctx, query, oldUsername, username); err != nil {
+			return fmt.Errorf("could not update notification actors: %w", err)
+		}
+
+		return nil
+	})
+}
+
+// RenamedUsername returns the current username of the user that used to have the given one.
+func (s *Service) RenamedUsername(ctx context.Context, oldUsername string) (string, error) {
+	oldUsername = strings.TrimSpace(oldUsername)
+	if !reUsername.MatchString(oldUsername) {
+		return "", ErrInvalidUsername
+	}
+
+	var username string
+	query := `
+		SELECT users.username FROM username_history
+		INNER JOIN users ON username_history.user_id = users.id
+		WHERE username_history.username = $1`
+	err := s.db.QueryRowContext(ctx, query, oldUsername).Scan(&username)
+	if err == sql.ErrNoRows {
+		return "", ErrUserNotFound
 	}
 
 	if err != nil {
-		return "", fmt.Errorf("could not read avatar: %w", err)
+		return "", fmt.Errorf("could not query select renamed username: %w", err)
 	}
 
-	if format != "png" && format != "jpeg" {
-		return "", ErrUnsupportedAvatarFormat
+	return username, nil
+}
+
+// UpdateAvatar of the authenticated user returning the new avatar URL.
+// 这里更新的头像只是将其生成了一个随机的ID作为头像文件名称，然后将上传的图片转换成几种尺寸后分别保存，
+// 文件通过 storage.Store 保存，可以是本地磁盘或者 S3 兼容的对象存储。
+func (s *Service) UpdateAvatar(ctx context.Context, r io.Reader) (string, error) {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return "", ErrUnauthenticated
 	}
 
-	avatar, err := gonanoid.Nanoid() //生存随机ID
+	b, err := ioutil.ReadAll(io.LimitReader(r, MaxAvatarBytes))
 	if err != nil {
-		return "", fmt.Errorf("could not generate avatar filename: %w", err)
+		return "", fmt.Errorf("could not read avatar: %w", err)
 	}
 
-	if format == "png" {
-		avatar += ".png"
-	} else {
-		avatar += ".jpg"
+	_, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err == image.ErrFormat {
+		return "", ErrUnsupportedAvatarFormat
 	}
 
-	avatarPath := path.Join(avatarsDir, avatar)
-	f, err := os.Create(avatarPath)
 	if err != nil {
-		return "", fmt.Errorf("could not create avatar file: %w", err)
+		return "", fmt.Errorf("could not read avatar config: %w", err)
 	}
 
-	defer f.Close()
-	// 将 img 进行转换，转换为 400x400的图片。
-	img = imaging.Fill(img, 400, 400, imaging.Center, imaging.CatmullRom)
-	if format == "png" {
-		err = png.Encode(f, img)
-	} else {
-		err = jpeg.Encode(f, img, nil)
+	if format != "png" && format != "jpeg" && format != "gif" && format != "webp" {
+		return "", ErrUnsupportedAvatarFormat
 	}
+
+	avatar, err := s.writeAvatar(ctx, b, format)
 	if err != nil {
-		return "", fmt.Errorf("could not write avatar to disk: %w", err)
+		return "", err
 	}
 
 	var oldAvatar sql.NullString
 	// 由于会先执行子查询，在没有更新之前的查询，那就是旧头像了，然后用 RETURNING 返回
 	if err = s.db.QueryRowContext(ctx, `
-		UPDATE users SET avatar = $1 WHERE id = $2
+		UPDATE users SET avatar = $1, avatar_variants = true WHERE id = $2
 		RETURNING (SELECT avatar FROM users WHERE id = $2) AS old_avatar`, avatar, uid).
 		Scan(&oldAvatar); err != nil {
-		defer os.Remove(avatarPath)
+		go s.removeAvatar(avatar)
 		return "", fmt.Errorf("could not update avatar: %w", err)
 	}
 
 	if oldAvatar.Valid {
-		defer os.Remove(path.Join(avatarsDir, oldAvatar.String)) //删除旧头像
+		go s.removeAvatar(oldAvatar.String) //删除旧头像
 	}
 
-	avatarURL := cloneURL(s.origin)
-	avatarURL.Path = "/img/avatars/" + avatar
+	return s.store.URL(avatarBlob(avatar)), nil
+}
+
+// DeleteAvatar of the authenticated user. The user goes back to its identicon.
+func (s *Service) DeleteAvatar(ctx context.Context) error {
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return ErrUnauthenticated
+	}
 
-	return avatarURL.String(), nil
+	var oldAvatar sql.NullString
+	if err := s.db.QueryRowContext(ctx, `
+		UPDATE users SET avatar = NULL WHERE id = $1
+		RETURNING (SELECT avatar FROM users WHERE id = $1) AS old_avatar`, uid).
+		Scan(&oldAvatar); err != nil {
+		return fmt.Errorf("could not delete avatar: %w", err)
+	}
+
+	if oldAvatar.Valid {
+		go s.removeAvatar(oldAvatar.String)
+	}
+
+	return nil
+}
+
+// UpdateUser profile fields of the authenticated user.
+// Nil fields are left untouched and empty ones are cleared.
+// Mentions in the bio are just text; nobody gets notified about them.
+// Making the account public again accepts all its pending follow requests.
+func (s *Service) UpdateUser(ctx context.Context, displayName, bio, location, website *string, private *bool) (UserProfile, error) {
+	var u UserProfile
+	uid, ok := ctx.Value(KeyAuthUserID).(string)
+	if !ok {
+		return u, ErrUnauthenticated
+	}
+
+	if displayName != nil {
+		*displayName = strings.Join(strings.Fields(*displayName), " ")
+		if utf8.RuneCountInString(*displayName) > maxDisplayNameLength {
+			return u, ErrInvalidDisplayName
+		}
+	}
+
+	if bio != nil {
+		*bio = smartTrim(*bio)
+		if utf8.RuneCountInString(*bio) > maxBioLength {
+			return u, ErrInvalidBio
+		}
+	}
+
+	if location != nil {
+		*location = strings.Join(strings.Fields(*location), " ")
+		if utf8.RuneCountInString(*location) > maxLocationLength {
+			return u, ErrInvalidLocation
+		}
+	}
+
+	if website != nil {
+		*website = strings.TrimSpace(*website)
+		if *website != "" {
+			if !strings.Contains(*website, "://") {
+				*website = "https://" + *website
+			}
+
+			wu, err := url.Parse(*website)
+			if err != nil || (wu.Scheme != "http" && wu.Scheme != "https") || wu.Host == "" ||
+				utf8.RuneCountInString(*website) > maxWebsiteLength {
+				return u, ErrInvalidWebsite
+			}
+		}
+	}
+
+	query, args, err := buildQuery(`
+		UPDATE users SET
+			display_name = {{if .setDisplayName}}@displayName{{else}}display_name{{end}},
+			bio = {{if .setBio}}@bio{{else}}bio{{end}},
+			location = {{if .setLocation}}@location{{else}}location{{end}},
+			website = {{if .setWebsite}}@website{{else}}website{{end}},
+			private = {{if .setPrivate}}@private{{else}}private{{end}}
+		WHERE id = @uid
+		RETURNING username`, map[string]interface{}{
+		"uid":            uid,
+		"setDisplayName": displayName != nil,
+		"displayName":    nullIfEmpty(displayName),
+		"setBio":         bio != nil,
+		"bio":            nullIfEmpty(bio),
+		"setLocation":    location != nil,
+		"location":       nullIfEmpty(location),
+		"setWebsite":     website != nil,
+		"website":        nullIfEmpty(website),
+		"setPrivate":     private != nil,
+		"private":        private,
+	})
+	if err != nil {
+		return u, fmt.Errorf("could not build update user sql query: %w", err)
+	}
+
+	var username string
+	var followerIDs []string
+	err = crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
+		followerIDs = nil
+
+		err := tx.QueryRowContext(ctx, query, args...).Scan(&username)
+		if err == sql.ErrNoRows {
+			return ErrUserNotFound
+		}
+
+		if err != nil {
+			return fmt.Errorf("could not update user: %w", err)
+		}
+
+		if private == nil || *private {
+			return nil
+		}
+
+		// Going public accepts all the pending follow requests.
+		query := `
+			WITH requests AS (
+				DELETE FROM follow_requests WHERE followee_id = $1
+				RETURNING follower_id
+			)
+			INSERT INTO follows (follower_id, followee_id)
+			SELECT follower_id, $1 FROM requests
+			ON CONFLICT DO NOTHING
+			RETURNING follower_id`
+		rows, err := tx.QueryContext(ctx, query, uid)
+		if err != nil {
+			return fmt.Errorf("could not accept pending follow requests: %w", err)
+		}
+
+		defer rows.Close()
+
+		for rows.Next() {
+			var followerID string
+			if err = rows.Scan(&followerID); err != nil {
+				return fmt.Errorf("could not scan accepted follow request: %w", err)
+			}
+
+			followerIDs = append(followerIDs, followerID)
+		}
+
+		if err = rows.Err(); err != nil {
+			return fmt.Errorf("could not iterate over accepted follow requests: %w", err)
+		}
+
+		if len(followerIDs) == 0 {
+			return nil
+		}
+
+		query = "UPDATE users SET followees_count = followees_count + 1 WHERE id = ANY($1)"
+		if _, err = tx.ExecContext(ctx, query, pq.Array(followerIDs)); err != nil {
+			return fmt.Errorf("could not increment followees count: %w", err)
+		}
+
+		query = "UPDATE users SET followers_count = followers_count + $1 WHERE id = $2"
+		if _, err = tx.ExecContext(ctx, query, len(followerIDs), uid); err != nil {
+			return fmt.Errorf("could not increment followers count: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return u, err
+	}
+
+	for _, followerID := range followerIDs {
+		go s.notifyFollow(followerID, uid)
+	}
+
+	return s.User(ctx, username)
 }
 
 // ToggleFollow between two users.
@@ -393,9 +787,10 @@ func (s *Service) ToggleFollow(ctx context.Context, username string) (ToggleFoll
 	}
 
 	var followeeID string
+	var private, request bool
 	err := crdb.ExecuteTx(ctx, s.db, nil, func(tx *sql.Tx) error {
-		query := "SELECT id FROM users WHERE username = $1"
-		err := tx.QueryRowContext(ctx, query, username).Scan(&followeeID)
+		query := "SELECT id, private FROM users WHERE username = $1"
+		err := tx.QueryRowContext(ctx, query, username).Scan(&followeeID, &private)
 		if err == sql.ErrNoRows {
 			return ErrUserNotFound
 		}
@@ -408,6 +803,15 @@ func (s *Service) ToggleFollow(ctx context.Context, username string) (ToggleFoll
 			return ErrForbiddenFollow
 		}
 
+		blocked, err := blockedBetween(ctx, tx, followerID, followeeID)
+		if err != nil {
+			return err
+		}
+
+		if blocked {
+			return ErrUserBlocked
+		}
+
 		// SELECT 1 FROM follows 是什么语法呢？？用来当做判断子查询是否成功，其效率比 SELECT * FROM follows要高
 		// SELECT 1 FROM follows WHERE follower_id = $1 AND followee_id = $2 表示只要WHERE 后面条件满足，就有返回值，什么结果无所谓
 		// EXISTS关键字: 如果子查询不为空，就返回TRUE。 SELECT EXISTS 就是获取子查询的结果TRUE 或者FALSE。
@@ -421,6 +825,22 @@ func (s *Service) ToggleFollow(ctx context.Context, username string) (ToggleFoll
 			return fmt.Errorf("could not query select existence of follow: %w", err)
 		}
 
+		// 私密账号需要先发起关注请求，等待对方同意
+		request = !out.Following && private
+		if request {
+			out.Requested, err = toggleFollowRequest(ctx, tx, followerID, followeeID)
+			if err != nil {
+				return err
+			}
+
+			query = "SELECT followers_count FROM users WHERE id = $1"
+			if err = tx.QueryRowContext(ctx, query, followeeID).Scan(&out.FollowersCount); err != nil {
+				return fmt.Errorf("could not query select followers count: %w", err)
+			}
+
+			return nil
+		}
+
 		if out.Following {
 			query = "DELETE FROM follows WHERE follower_id = $1 AND followee_id = $2"
 			_, err = tx.ExecContext(ctx, query, followerID, followeeID)
@@ -468,6 +888,13 @@ func (s *Service) ToggleFollow(ctx context.Context, username string) (ToggleFoll
 		return out, err
 	}
 
+	if request {
+		if out.Requested {
+			go s.notifyFollowRequest(followerID, followeeID)
+		}
+		return out, nil
+	}
+
 	out.Following = !out.Following
 
 	if out.Following {
@@ -484,11 +911,21 @@ func (s *Service) Followers(ctx context.Context, username string, first int, aft
 		return nil, ErrInvalidUsername
 	}
 
+	hidden, err := s.hiddenAccount(ctx, ownerByUsername, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if hidden {
+		return nil, ErrPrivateAccount
+	}
+
 	first = normalizePageSize(first)
 	after = strings.TrimSpace(after)
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	query, args, err := buildQuery(`
 		SELECT id, email, username, avatar, followers_count, followees_count
+		, display_name, bio, location, website
 		{{if .auth}}
 		, followers.follower_id IS NOT NULL AS following
 		, followees.followee_id IS NOT NULL AS followeed
@@ -532,6 +969,10 @@ func (s *Service) Followers(ctx context.Context, username string, first int, aft
 			&avatar,
 			&u.FollowersCount,
 			&u.FolloweesCount,
+			&u.DisplayName,
+			&u.Bio,
+			&u.Location,
+			&u.Website,
 		}
 		if auth {
 			dest = append(dest, &u.Following, &u.Followeed)
@@ -545,7 +986,8 @@ func (s *Service) Followers(ctx context.Context, username string, first int, aft
 			u.ID = ""
 			u.Email = ""
 		}
-		u.AvatarURL = s.avatarURL(avatar)
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
 		uu = append(uu, u)
 	}
 
@@ -563,11 +1005,21 @@ func (s *Service) Followees(ctx context.Context, username string, first int, aft
 		return nil, ErrInvalidUsername
 	}
 
+	hidden, err := s.hiddenAccount(ctx, ownerByUsername, username)
+	if err != nil {
+		return nil, err
+	}
+
+	if hidden {
+		return nil, ErrPrivateAccount
+	}
+
 	first = normalizePageSize(first)
 	after = strings.TrimSpace(after)
 	uid, auth := ctx.Value(KeyAuthUserID).(string)
 	query, args, err := buildQuery(`
 		SELECT id, email, username, avatar, followers_count, followees_count
+		, display_name, bio, location, website
 		{{if .auth}}
 		, followers.follower_id IS NOT NULL AS following
 		, followees.followee_id IS NOT NULL AS followeed
@@ -611,6 +1063,10 @@ func (s *Service) Followees(ctx context.Context, username string, first int, aft
 			&avatar,
 			&u.FollowersCount,
 			&u.FolloweesCount,
+			&u.DisplayName,
+			&u.Bio,
+			&u.Location,
+			&u.Website,
 		}
 		if auth {
 			dest = append(dest, &u.Following, &u.Followeed)
@@ -624,7 +1080,8 @@ func (s *Service) Followees(ctx context.Context, username string, first int, aft
 			u.ID = ""
 			u.Email = ""
 		}
-		u.AvatarURL = s.avatarURL(avatar)
+		u.AvatarURL = s.avatarURL(u.Username, avatar)
+		u.AvatarURLs = s.avatarURLs(u.Username, avatar)
 		uu = append(uu, u)
 	}
 
@@ -634,14 +1091,3 @@ func (s *Service) Followees(ctx context.Context, username string, first int, aft
 
 	return uu, nil
 }
-
-func (s *Service) avatarURL(avatar sql.NullString) *string {
-	if !avatar.Valid {
-		return nil
-	}
-
-	avatarURL := cloneURL(s.origin)
-	avatarURL.Path = "/img/avatars/" + avatar.String
-	str := avatarURL.String()
-	return &str
-}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// maxUserSearchLength is the max number of characters of a user search.
+// Longer ones get truncated.
+const maxUserSearchLength = 64
+
+// Ranked user search pieces shared by Users and Usernames.
+// Users match by a username prefix or fuzzily by username and display name
+// through the trigram index on users.search_name.
+// They expect the search, contains, prefix, auth and uid query data.
+const (
+	// userInteractions selects how many times the authenticated user interacted
+	// with each other user in the last 90 days: liking or commenting their posts,
+	// mentioning them or talking to them.
+	userInteractions = `
+		SELECT user_id, count(*) AS interactions_count FROM (
+			SELECT posts.user_id FROM post_likes
+			INNER JOIN posts ON post_likes.post_id = posts.id
+			WHERE post_likes.user_id = @uid AND post_likes.created_at > now() - INTERVAL '90 days'
+			UNION ALL
+			SELECT posts.user_id FROM comments
+			INNER JOIN posts ON comments.post_id = posts.id
+			WHERE comments.user_id = @uid AND comments.created_at > now() - INTERVAL '90 days'
+			UNION ALL
+			SELECT post_mentions.user_id FROM post_mentions
+			INNER JOIN posts ON post_mentions.post_id = posts.id
+			WHERE posts.user_id = @uid AND posts.created_at > now() - INTERVAL '90 days'
+			UNION ALL
+			SELECT others.user_id FROM conversation_members AS mine
+			INNER JOIN conversation_members AS others
+				ON others.conversation_id = mine.conversation_id AND others.user_id != mine.user_id
+			INNER JOIN conversations ON conversations.id = mine.conversation_id
+			WHERE mine.user_id = @uid AND conversations.updated_at > now() - INTERVAL '90 days'
+		) AS interacted
+		GROUP BY user_id`
+
+	// userSearchJoins brings the follows from the authenticated user
+	// and how much they interacted with each other user.
+	userSearchJoins = `
+		{{if .auth}}
+		LEFT JOIN follows AS followers
+			ON followers.follower_id = @uid AND followers.followee_id = users.id
+		LEFT JOIN (` + userInteractions + `) AS interactions ON interactions.user_id = users.id
+		{{end}}`
+
+	userSearchMatch = `(
+		lower(users.username) LIKE @prefix
+		OR users.search_name LIKE @contains
+		OR users.search_name % @search
+	)`
+
+	// userSearchScore ranks followees first, then the users interacted with,
+	// then popular ones, with username prefix matches and closer spellings going up.
+	userSearchScore = `(
+		similarity(users.search_name, @search)::FLOAT8
+		+ (CASE WHEN lower(users.username) LIKE @prefix THEN 1 ELSE 0 END)::FLOAT8
+		{{if .auth}}
+		+ (CASE WHEN followers.follower_id IS NOT NULL THEN 2 ELSE 0 END)::FLOAT8
+		+ least(COALESCE(interactions.interactions_count, 0), 20)::FLOAT8 / 10
+		{{end}}
+		+ ln(users.followers_count::FLOAT8 + 1) / 10
+	)`
+)
+
+// userSearchData normalizes the search
+// and fills the query data userSearchMatch and userSearchScore expect.
+func userSearchData(search string, data map[string]interface{}) {
+	search = strings.ToLower(strings.Join(strings.Fields(strings.TrimPrefix(strings.TrimSpace(search), "@")), " "))
+	if utf8.RuneCountInString(search) > maxUserSearchLength {
+		search = string([]rune(search)[:maxUserSearchLength])
+	}
+
+	escaped := likeEscape(search)
+	data["search"] = search
+	data["contains"] = "%" + escaped + "%"
+	data["prefix"] = escaped + "%"
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// likeEscape escapes the LIKE pattern wildcards of s.
+func likeEscape(s string) string {
+	return likeEscaper.Replace(s)
+}
@@ -2,12 +2,16 @@ package service
 
 import (
 	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"regexp"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
 	"github.com/lib/pq"
 )
@@ -24,6 +28,7 @@ var (
 	reMultiSpace          = regexp.MustCompile(`(\s)+`)
 	reMoreThan2Linebreaks = regexp.MustCompile(`(\n){2,}`)
 	reMentions            = regexp.MustCompile(`\B@([a-zA-Z][a-zA-Z0-9_-]{0,17})`)
+	reTags                = regexp.MustCompile(`\B#(\p{L}[\p{L}\p{N}_]{0,63})`)
 )
 
 func isUniqueViolation(err error) bool {
@@ -107,6 +112,43 @@ func collectMentions(s string) []string {
 	return u
 }
 
+// collectTags returns the lowercased hashtags of s without the "#".
+func collectTags(s string) []string {
+	m := map[string]struct{}{}
+	t := []string{}
+	for _, submatch := range reTags.FindAllStringSubmatch(s, -1) {
+		val := strings.ToLower(submatch[1])
+		if _, ok := m[val]; !ok {
+			m[val] = struct{}{}
+			t = append(t, val)
+		}
+	}
+	return t
+}
+
+// addedMentions returns the mentions present in the new content but not in the old one.
+func addedMentions(oldContent, newContent string) []string {
+	old := map[string]struct{}{}
+	for _, u := range collectMentions(oldContent) {
+		old[u] = struct{}{}
+	}
+
+	added := []string{}
+	for _, u := range collectMentions(newContent) {
+		if _, ok := old[u]; !ok {
+			added = append(added, u)
+		}
+	}
+	return added
+}
+
+func equalStringPtr(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
 func cloneURL(u *url.URL) *url.URL {
 	if u == nil {
 		return nil
@@ -119,3 +161,60 @@ func cloneURL(u *url.URL) *url.URL {
 	}
 	return u2
 }
+
+// nullIfEmpty so an empty optional string gets stored as NULL.
+func nullIfEmpty(s *string) interface{} {
+	if s == nil || *s == "" {
+		return nil
+	}
+	return *s
+}
+
+// ErrInvalidCursor denotes an invalid pagination cursor.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// encodeCursor into an opaque pagination cursor.
+func encodeCursor(v interface{}) string {
+	b, _ := json.Marshal(v)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor out of encodeCursor into v.
+func decodeCursor(s string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+// PageInfo of a cursor paginated list.
+// NextCursor is nil on the last page.
+type PageInfo struct {
+	NextCursor *string `json:"nextCursor"`
+	HasMore    bool    `json:"hasMore"`
+}
+
+// nextPage info pointing to the given cursor.
+func nextPage(cursor interface{}) PageInfo {
+	next := encodeCursor(cursor)
+	return PageInfo{NextCursor: &next, HasMore: true}
+}
+
+// timeCursor points to an item of a list sorted by time and then by id,
+// so items sharing the same time don't get skipped nor repeated.
+type timeCursor struct {
+	Time time.Time `json:"t"`
+	ID   string    `json:"i"`
+}
+
+// decodeTimeCursor out of a timeCursor encoded with encodeCursor.
+func decodeTimeCursor(s string) (timeCursor, error) {
+	var c timeCursor
+	if err := decodeCursor(s, &c); err != nil || c.Time.IsZero() || !reUUID.MatchString(c.ID) {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
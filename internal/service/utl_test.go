@@ -0,0 +1,68 @@
+package service
+
+import (
+	"encoding/base64"
+	"testing"
+	"time"
+)
+
+func Test_decodeTimeCursor(t *testing.T) {
+	valid := timeCursor{
+		Time: time.Date(2020, time.May, 4, 13, 2, 1, 500, time.UTC),
+		ID:   "8f4e1d1a-2c5b-4e3a-9f6d-0a1b2c3d4e5f",
+	}
+
+	tt := []struct {
+		name    string
+		cursor  string
+		want    timeCursor
+		wantErr error
+	}{
+		{
+			name:   "round_trip",
+			cursor: encodeCursor(valid),
+			want:   valid,
+		},
+		{
+			name:    "empty",
+			cursor:  "",
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "tampered_base64",
+			cursor:  encodeCursor(valid)[1:] + "!",
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "not_json",
+			cursor:  base64.RawURLEncoding.EncodeToString([]byte("nope")),
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "zero_time",
+			cursor:  encodeCursor(timeCursor{ID: valid.ID}),
+			wantErr: ErrInvalidCursor,
+		},
+		{
+			name:    "non_uuid",
+			cursor:  encodeCursor(timeCursor{Time: valid.Time, ID: "nope"}),
+			wantErr: ErrInvalidCursor,
+		},
+	}
+	for _, tc := range tt {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := decodeTimeCursor(tc.cursor)
+			if err != tc.wantErr {
+				t.Fatalf("want error %v; got %v", tc.wantErr, err)
+			}
+
+			if tc.wantErr != nil {
+				return
+			}
+
+			if !got.Time.Equal(tc.want.Time) || got.ID != tc.want.ID {
+				t.Errorf("want %+v; got %+v", tc.want, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// Post visibility levels.
+const (
+	// VisibilityPublic posts can be seen by anyone.
+	VisibilityPublic = "public"
+	// VisibilityFollowers posts can be seen by the author followers.
+	VisibilityFollowers = "followers"
+	// VisibilityMentioned posts can be seen by the users mentioned in them only.
+	VisibilityMentioned = "mentioned"
+)
+
+// ErrInvalidVisibility denotes an unknown post visibility level.
+var ErrInvalidVisibility = errors.New("invalid visibility")
+
+// visiblePost is the sql template condition of a post the viewer can see.
+// Authors see all their posts and mentioned users see the posts they are mentioned in,
+// whatever its visibility.
+// It expects the posts table, and the auth and uid query data.
+const visiblePost = `(posts.visibility = 'public'{{if .auth}}
+	OR posts.user_id = @uid
+	OR (posts.visibility = 'followers' AND EXISTS (
+		SELECT 1 FROM follows WHERE follower_id = @uid AND followee_id = posts.user_id
+	))
+	OR EXISTS (SELECT 1 FROM post_mentions WHERE post_mentions.post_id = posts.id AND post_mentions.user_id = @uid){{end}})`
+
+// Queries for hiddenPost selecting the post out of @id.
+const (
+	postByID      = "SELECT @id::UUID"
+	postByComment = "SELECT post_id FROM comments WHERE id = @id"
+)
+
+func validVisibility(visibility string) bool {
+	switch visibility {
+	case VisibilityPublic, VisibilityFollowers, VisibilityMentioned:
+		return true
+	}
+	return false
+}
+
+// hiddenPost reports whether the visibility of the post selected by postQuery
+// leaves the authenticated user out. Unknown posts are not hidden.
+func (s *Service) hiddenPost(ctx context.Context, postQuery, id string) (bool, error) {
+	uid, auth := ctx.Value(KeyAuthUserID).(string)
+	query, args, err := buildQuery(`
+		SELECT NOT `+visiblePost+`
+		FROM posts WHERE id = (`+postQuery+`)`, map[string]interface{}{
+		"auth": auth,
+		"uid":  uid,
+		"id":   id,
+	})
+	if err != nil {
+		return false, fmt.Errorf("could not build hidden post sql query: %w", err)
+	}
+
+	var hidden bool
+	err = s.db.QueryRowContext(ctx, query, args...).Scan(&hidden)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, fmt.Errorf("could not query select post visibility: %w", err)
+	}
+
+	return hidden, nil
+}
+
+// insertPostMentions records the users mentioned in a post,
+// so they can see it regardless of its visibility. The author is left out.
+func insertPostMentions(ctx context.Context, tx *sql.Tx, postID, authorID string, mentions []string) error {
+	if len(mentions) == 0 {
+		return nil
+	}
+
+	query := `
+		INSERT INTO post_mentions (post_id, user_id)
+		SELECT $1, id FROM users WHERE username = ANY($2) AND id != $3
+		ON CONFLICT (post_id, user_id) DO NOTHING`
+	if _, err := tx.ExecContext(ctx, query, postID, pq.Array(mentions), authorID); err != nil {
+		return fmt.Errorf("could not insert post mentions: %w", err)
+	}
+
+	return nil
+}
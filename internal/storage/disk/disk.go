@@ -0,0 +1,78 @@
+package disk
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/nicolasparada/nakama/internal/storage"
+)
+
+// Store implementation using the local filesystem.
+// Only suitable when running a single replica.
+type Store struct {
+	// Dir in which blobs are written.
+	Dir string
+	// BaseURL from which Dir is served.
+	BaseURL *url.URL
+}
+
+// Put writes the blob to disk creating any missing directory.
+func (s *Store) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	p := s.path(name)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+
+	f, err := os.Create(p)
+	if err != nil {
+		return err
+	}
+
+	if _, err = io.Copy(f, r); err != nil {
+		f.Close()
+		os.Remove(p)
+		return err
+	}
+
+	return f.Close()
+}
+
+// Get opens the blob for reading.
+func (s *Store) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	f, err := os.Open(s.path(name))
+	if os.IsNotExist(err) {
+		return nil, storage.ErrNotFound
+	}
+
+	return f, err
+}
+
+// Delete removes the blob. Removing a missing blob is not an error.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	err := os.Remove(s.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+
+	return err
+}
+
+// URL in which the blob can be fetched.
+func (s *Store) URL(name string) string {
+	u := *s.BaseURL
+	u.Path = path.Join(u.Path, clean(name))
+	return u.String()
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.Dir, filepath.FromSlash(clean(name)))
+}
+
+// clean keeps names inside the store root.
+func clean(name string) string {
+	return path.Clean("/" + name)
+}
@@ -0,0 +1,90 @@
+package disk
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/nicolasparada/nakama/internal/storage"
+)
+
+func newTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+
+	root := t.TempDir()
+	baseURL, err := url.Parse("http://localhost:3000/media")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return &Store{Dir: filepath.Join(root, "media"), BaseURL: baseURL}, root
+}
+
+func TestStore(t *testing.T) {
+	s, _ := newTestStore(t)
+	ctx := context.Background()
+	name := "avatars/test.txt"
+	want := []byte("hello, world")
+
+	if err := s.Put(ctx, name, bytes.NewReader(want), "text/plain"); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+
+	rc, err := s.Get(ctx, name)
+	if err != nil {
+		t.Fatalf("Get() = %v", err)
+	}
+
+	got, err := ioutil.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !bytes.Equal(got, want) {
+		t.Errorf("Get() body = %q; want %q", got, want)
+	}
+
+	if got, want := s.URL(name), "http://localhost:3000/media/avatars/test.txt"; got != want {
+		t.Errorf("URL() = %q; want %q", got, want)
+	}
+
+	if err = s.Delete(ctx, name); err != nil {
+		t.Fatalf("Delete() = %v", err)
+	}
+
+	if _, err = s.Get(ctx, name); !errors.Is(err, storage.ErrNotFound) {
+		t.Errorf("Get() after Delete() = %v; want %v", err, storage.ErrNotFound)
+	}
+
+	if err = s.Delete(ctx, name); err != nil {
+		t.Errorf("Delete() of a missing blob = %v; want nil", err)
+	}
+}
+
+func TestStore_dotDot(t *testing.T) {
+	s, root := newTestStore(t)
+	ctx := context.Background()
+	name := "../../escaped.txt"
+
+	if err := s.Put(ctx, name, bytes.NewReader([]byte("nope")), "text/plain"); err != nil {
+		t.Fatalf("Put() = %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(root, "escaped.txt")); !os.IsNotExist(err) {
+		t.Errorf("Put() wrote outside the store dir: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(s.Dir, "escaped.txt")); err != nil {
+		t.Errorf("Put() didn't write inside the store dir: %v", err)
+	}
+
+	if got, want := s.URL(name), "http://localhost:3000/media/escaped.txt"; got != want {
+		t.Errorf("URL() = %q; want %q", got, want)
+	}
+}
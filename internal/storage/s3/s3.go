@@ -0,0 +1,232 @@
+package s3
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nicolasparada/nakama/internal/storage"
+)
+
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// Store implementation using an S3 compatible object storage like AWS S3 or MinIO.
+// Requests use path-style addressing: {Endpoint}/{Bucket}/{name}.
+type Store struct {
+	Endpoint        *url.URL
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+	// PublicURL from which objects are served. Defaults to {Endpoint}/{Bucket}.
+	PublicURL *url.URL
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+
+	now func() time.Time
+}
+
+// Put uploads the blob as an object.
+func (s *Store) Put(ctx context.Context, name string, r io.Reader, contentType string) error {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("could not read s3 object body: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(name), bytes.NewReader(b))
+	if err != nil {
+		return fmt.Errorf("could not create s3 put request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", contentType)
+	sum := sha256.Sum256(b)
+	resp, err := s.do(req, hex.EncodeToString(sum[:]))
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+	return nil
+}
+
+// Get downloads the object.
+func (s *Store) Get(ctx context.Context, name string) (io.ReadCloser, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(name), nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create s3 get request: %w", err)
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Body, nil
+}
+
+// Delete removes the object. S3 doesn't fail when the object is missing.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(name), nil)
+	if err != nil {
+		return fmt.Errorf("could not create s3 delete request: %w", err)
+	}
+
+	resp, err := s.do(req, emptyPayloadHash)
+	if err != nil {
+		return err
+	}
+
+	resp.Body.Close()
+	return nil
+}
+
+// URL in which the object can be fetched.
+func (s *Store) URL(name string) string {
+	var u url.URL
+	if s.PublicURL != nil {
+		u = *s.PublicURL
+	} else {
+		u = *s.Endpoint
+		u.Path = path.Join(u.Path, s.Bucket)
+	}
+	u.Path = path.Join(u.Path, clean(name))
+	return u.String()
+}
+
+func (s *Store) objectURL(name string) string {
+	u := *s.Endpoint
+	u.Path = path.Join(u.Path, s.Bucket, clean(name))
+	return u.String()
+}
+
+func (s *Store) do(req *http.Request, payloadHash string) (*http.Response, error) {
+	now := time.Now
+	if s.now != nil {
+		now = s.now
+	}
+	s.sign(req, payloadHash, now())
+
+	client := s.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not do s3 %s request: %w", req.Method, err)
+	}
+
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, storage.ErrNotFound
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		b, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 1<<10))
+		resp.Body.Close()
+		return nil, fmt.Errorf("unexpected s3 %s response status %d: %s", req.Method, resp.StatusCode, b)
+	}
+
+	return resp, nil
+}
+
+// sign adds an AWS signature version 4 authorization header to the request.
+// Every header already present in the request gets signed along with the host.
+func (s *Store) sign(req *http.Request, payloadHash string, t time.Time) {
+	t = t.UTC()
+	amzDate := t.Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	host := req.Host
+	if host == "" {
+		host = req.URL.Host
+	}
+
+	headers := map[string]string{"host": host}
+	for k, vv := range req.Header {
+		headers[strings.ToLower(k)] = strings.TrimSpace(strings.Join(vv, ","))
+	}
+
+	names := make([]string, 0, len(headers))
+	for k := range headers {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, k := range names {
+		canonicalHeaders.WriteString(k + ":" + headers[k] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + s.Region + "/s3/aws4_request"
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(canonicalRequestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.SecretAccessKey), date)
+	key = hmacSHA256(key, s.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature,
+	))
+}
+
+func canonicalQuery(q url.Values) string {
+	keys := make([]string, 0, len(q))
+	for k := range q {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := []string{}
+	for _, k := range keys {
+		vv := q[k]
+		sort.Strings(vv)
+		for _, v := range vv {
+			pairs = append(pairs, escape(k)+"="+escape(v))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// escape as required by AWS: everything but unreserved characters, spaces as %20.
+func escape(s string) string {
+	return strings.Replace(url.QueryEscape(s), "+", "%20", -1)
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// clean keeps names inside the bucket.
+func clean(name string) string {
+	return path.Clean("/" + name)
+}
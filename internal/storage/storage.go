@@ -0,0 +1,18 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound denotes a not found blob.
+var ErrNotFound = errors.New("blob not found")
+
+// Store persists blobs like avatars and media under a slash separated name.
+type Store interface {
+	Put(ctx context.Context, name string, r io.Reader, contentType string) error
+	Get(ctx context.Context, name string) (io.ReadCloser, error)
+	Delete(ctx context.Context, name string) error
+	URL(name string) string
+}
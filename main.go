@@ -22,6 +22,9 @@ import (
 	"github.com/nicolasparada/nakama/internal/mailing"
 	"github.com/nicolasparada/nakama/internal/pubsub/nats"
 	"github.com/nicolasparada/nakama/internal/service"
+	"github.com/nicolasparada/nakama/internal/storage"
+	"github.com/nicolasparada/nakama/internal/storage/disk"
+	"github.com/nicolasparada/nakama/internal/storage/s3"
 )
 
 func main() {
@@ -42,10 +45,17 @@ func run() error {
 		smtpPort, _  = strconv.Atoi(env("SMTP_PORT", "25"))
 		smtpUsername = os.Getenv("SMTP_USERNAME")
 		smtpPassword = os.Getenv("SMTP_PASSWORD")
+		s3Endpoint   = os.Getenv("S3_ENDPOINT")
+		s3Region     = env("S3_REGION", "us-east-1")
+		s3Bucket     = os.Getenv("S3_BUCKET")
+		s3AccessKey  = os.Getenv("S3_ACCESS_KEY")
+		s3SecretKey  = os.Getenv("S3_SECRET_KEY")
+		s3PublicURL  = os.Getenv("S3_PUBLIC_URL")
 	)
 	flag.Usage = func() {
 		flag.PrintDefaults()
 		fmt.Println("\nDon't forget to set TOKEN_KEY, SMTP_USERNAME and SMTP_PASSWORD for real usage.")
+		fmt.Println("Set S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY to store avatars and media in S3 instead of the local disk.")
 	}
 	flag.IntVar(&port, "port", port, "Port in which this server will run")
 	flag.StringVar(&originStr, "origin", originStr, "URL origin for this service")
@@ -53,6 +63,10 @@ func run() error {
 	flag.StringVar(&natsURL, "nats", natsURL, "NATS URL")
 	flag.StringVar(&smtpHost, "smtp-host", smtpHost, "SMTP server host")
 	flag.IntVar(&smtpPort, "smtp-port", smtpPort, "SMTP server port")
+	flag.StringVar(&s3Endpoint, "s3-endpoint", s3Endpoint, "S3 compatible object storage endpoint")
+	flag.StringVar(&s3Region, "s3-region", s3Region, "S3 region")
+	flag.StringVar(&s3Bucket, "s3-bucket", s3Bucket, "S3 bucket")
+	flag.StringVar(&s3PublicURL, "s3-public-url", s3PublicURL, "URL from which S3 objects are served")
 	flag.Parse()
 
 	origin, err := url.Parse(originStr)
@@ -99,6 +113,36 @@ func run() error {
 		)
 
 	}
+	var store storage.Store
+	if s3Endpoint == "" {
+		store = &disk.Store{
+			Dir:     "web/static/img",
+			BaseURL: &url.URL{Scheme: origin.Scheme, Host: origin.Host, Path: "/img/"},
+		}
+	} else {
+		endpoint, err := url.Parse(s3Endpoint)
+		if err != nil || !endpoint.IsAbs() {
+			return errors.New("invalid s3 endpoint")
+		}
+
+		var publicURL *url.URL
+		if s3PublicURL != "" {
+			publicURL, err = url.Parse(s3PublicURL)
+			if err != nil || !publicURL.IsAbs() {
+				return errors.New("invalid s3 public url")
+			}
+		}
+
+		store = &s3.Store{
+			Endpoint:        endpoint,
+			Region:          s3Region,
+			Bucket:          s3Bucket,
+			AccessKeyID:     s3AccessKey,
+			SecretAccessKey: s3SecretKey,
+			PublicURL:       publicURL,
+		}
+	}
+
 	service := service.New(service.Conf{
 		DB:          db,
 		Sender:      sender,
@@ -106,6 +150,7 @@ func run() error {
 		TemplateDir: "web/template",
 		TokenKey:    tokenKey,
 		PubSub:      pubsub,
+		Store:       store,
 	})
 	server := http.Server{
 		Addr:              fmt.Sprintf(":%d", port),
@@ -9,13 +9,32 @@ CREATE TABLE IF NOT EXISTS users (
     email VARCHAR NOT NULL UNIQUE,
     username VARCHAR NOT NULL UNIQUE,
     avatar VARCHAR, -- 头像
+    avatar_variants BOOL NOT NULL DEFAULT false, -- whether the avatar size variants were generated
+    display_name VARCHAR,
+    bio VARCHAR,
+    location VARCHAR,
+    website VARCHAR,
+    private BOOL NOT NULL DEFAULT false, -- only approved followers can see the content
     followers_count INT NOT NULL DEFAULT 0 CHECK (followers_count >= 0), -- 关注我的用户数量
-    followees_count INT NOT NULL DEFAULT 0 CHECK (followees_count >= 0) -- 我关注的用户数量
+    followees_count INT NOT NULL DEFAULT 0 CHECK (followees_count >= 0), -- 我关注的用户数量
+    search_name VARCHAR AS (lower(username || ' ' || COALESCE(display_name, ''))) STORED -- for the user search
+);
+
+CREATE INDEX IF NOT EXISTS prefixed_usernames ON users (lower(username));
+
+CREATE INVERTED INDEX IF NOT EXISTS searchable_users ON users (search_name gin_trgm_ops);
+
+-- Previous usernames, so old handles can redirect to the current one.
+CREATE TABLE IF NOT EXISTS username_history (
+    username VARCHAR NOT NULL PRIMARY KEY,
+    user_id UUID NOT NULL REFERENCES users,
+    changed_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
 CREATE TABLE IF NOT EXISTS verification_codes (
     id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID NOT NULL REFERENCES users,
+    email VARCHAR, -- set when the code confirms an email change instead of a login
     created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
@@ -25,6 +44,36 @@ CREATE TABLE IF NOT EXISTS follows (
     PRIMARY KEY (follower_id, followee_id) -- 联合主键
 );
 
+-- Pending follows to private accounts. Once accepted they move to follows.
+CREATE TABLE IF NOT EXISTS follow_requests (
+    follower_id UUID NOT NULL REFERENCES users,
+    followee_id UUID NOT NULL REFERENCES users,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (follower_id, followee_id)
+);
+
+-- Users that don't want to see or interact with another one.
+CREATE TABLE IF NOT EXISTS blocks (
+    blocker_id UUID NOT NULL REFERENCES users,
+    blocked_id UUID NOT NULL REFERENCES users,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (blocker_id, blocked_id)
+);
+
+-- Users or words a user doesn't want to see for a while.
+-- Each mute has either a muted_user_id or a lowercased word. No expiry means forever.
+CREATE TABLE IF NOT EXISTS mutes (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users,
+    muted_user_id UUID REFERENCES users,
+    word VARCHAR,
+    expires_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK ((muted_user_id IS NULL) != (word IS NULL)),
+    UNIQUE (user_id, muted_user_id),
+    UNIQUE (user_id, word)
+);
+
 -- 记录一次发帖的表
 CREATE TABLE IF NOT EXISTS posts (
     id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(), -- 帖子的ID
@@ -32,17 +81,60 @@ CREATE TABLE IF NOT EXISTS posts (
     content VARCHAR NOT NULL,  -- 帖子内容
     spoiler_of VARCHAR, -- 设置的是黑名单吗？？
     nsfw BOOLEAN NOT NULL DEFAULT false, -- not-safe-for-work（不安全的工作方式），这用来标记这个帖子是否有不安全的信息，用来进行警告用户
+    visibility VARCHAR NOT NULL DEFAULT 'public' CHECK (visibility IN ('public', 'followers', 'mentioned')),
     likes_count INT NOT NULL DEFAULT 0 CHECK (likes_count >= 0), -- 帖子点赞数量
     comments_count INT NOT NULL DEFAULT 0 CHECK (comments_count >= 0), --评论数量
-    created_at TIMESTAMPTZ NOT NULL DEFAULT now() --发帖时间
+    reposts_count INT NOT NULL DEFAULT 0 CHECK (reposts_count >= 0),
+    quoted_post_id UUID, -- no foreign key so quoting posts survive the quoted one being deleted
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(), --发帖时间
+    fanned_out BOOLEAN NOT NULL DEFAULT true, -- false when the author had too many followers; timelines merge it on read
+    search_vector TSVECTOR AS (to_tsvector('english', content)) STORED -- full-text search over the content
+);
+
+CREATE INDEX IF NOT EXISTS sorted_posts ON posts (created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS sorted_pulled_posts ON posts (user_id, created_at DESC, id DESC) WHERE NOT fanned_out;
+
+-- Candidates for the ranked timeline from outside the user network. Keep in sync with trendingPosts.
+CREATE INDEX IF NOT EXISTS trending_posts ON posts (created_at DESC) WHERE likes_count + comments_count + reposts_count >= 10;
+
+CREATE INVERTED INDEX IF NOT EXISTS searchable_posts ON posts (search_vector);
+
+-- Users mentioned in a post. They can see it whatever its visibility.
+CREATE TABLE IF NOT EXISTS post_mentions (
+    post_id UUID NOT NULL REFERENCES posts,
+    user_id UUID NOT NULL REFERENCES users,
+    PRIMARY KEY (post_id, user_id)
+);
+
+-- Images attached to a post. Files live in web/static/img/media.
+CREATE TABLE IF NOT EXISTS post_media (
+    post_id UUID NOT NULL REFERENCES posts,
+    position INT NOT NULL,
+    file VARCHAR NOT NULL,
+    thumbnail VARCHAR NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    PRIMARY KEY (post_id, position)
+);
+
+-- Previous versions of a post, stored each time its author edits it.
+CREATE TABLE IF NOT EXISTS post_revisions (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    post_id UUID NOT NULL REFERENCES posts,
+    content VARCHAR NOT NULL,
+    spoiler_of VARCHAR,
+    nsfw BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
-CREATE INDEX IF NOT EXISTS sorted_posts ON posts (created_at DESC);
+CREATE INDEX IF NOT EXISTS sorted_post_revisions ON post_revisions (post_id, created_at DESC);
 
 -- 帖子点赞的表
 CREATE TABLE IF NOT EXISTS post_likes (
     user_id UUID NOT NULL REFERENCES users,
     post_id UUID NOT NULL REFERENCES posts,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
     PRIMARY KEY (user_id, post_id)
 );
 
@@ -53,26 +145,70 @@ CREATE TABLE IF NOT EXISTS post_subscriptions (
     PRIMARY KEY (user_id, post_id)
 );
 
+-- Users that shared someone else's post with their followers.
+CREATE TABLE IF NOT EXISTS reposts (
+    user_id UUID NOT NULL REFERENCES users,
+    post_id UUID NOT NULL REFERENCES posts,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    fanned_out BOOLEAN NOT NULL DEFAULT true, -- false when the reposter had too many followers; timelines merge it on read
+    PRIMARY KEY (user_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS sorted_pulled_reposts ON reposts (user_id, created_at DESC) WHERE NOT fanned_out;
+
 -- 时间线表，目前还不清楚作用
 CREATE TABLE IF NOT EXISTS timeline (
     id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID NOT NULL REFERENCES users,
-    post_id UUID NOT NULL REFERENCES posts
+    post_id UUID NOT NULL REFERENCES posts,
+    reposted_by UUID REFERENCES users, -- set when the item got here through a repost
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
 CREATE UNIQUE INDEX IF NOT EXISTS unique_timeline_items ON timeline (user_id, post_id);
 
+CREATE INDEX IF NOT EXISTS sorted_timeline_items ON timeline (user_id, created_at DESC, id DESC);
+
+-- Posts merged into timelines on read that the user took out of theirs.
+CREATE TABLE IF NOT EXISTS timeline_hides (
+    user_id UUID NOT NULL REFERENCES users,
+    post_id UUID NOT NULL REFERENCES posts,
+    PRIMARY KEY (user_id, post_id)
+);
+
+-- Snapshots of the ranked "For You" timeline so paginating through one is stable.
+-- Each refresh makes a new one replacing the previous ones from the user.
+CREATE TABLE IF NOT EXISTS ranked_timeline (
+    snapshot_id UUID NOT NULL,
+    user_id UUID NOT NULL REFERENCES users,
+    post_id UUID NOT NULL REFERENCES posts,
+    score FLOAT8 NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (snapshot_id, post_id)
+);
+
+CREATE INDEX IF NOT EXISTS sorted_ranked_timeline ON ranked_timeline (snapshot_id, score DESC, post_id DESC);
+
+CREATE INDEX IF NOT EXISTS ranked_timeline_snapshots ON ranked_timeline (user_id, created_at);
+
 -- 评论表
 CREATE TABLE IF NOT EXISTS comments (
     id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(), -- 评论的id
     user_id UUID NOT NULL REFERENCES users, -- 评论的用户id
     post_id UUID NOT NULL REFERENCES posts,  -- 评论的帖子
+    parent_id UUID REFERENCES comments, -- top-level comment this one replies to
     content VARCHAR NOT NULL, --评论的内容
     likes_count INT NOT NULL DEFAULT 0 CHECK (likes_count >= 0), -- 评论的点赞数量
-    created_at TIMESTAMPTZ NOT NULL DEFAULT now() -- 评论发布时间
+    replies_count INT NOT NULL DEFAULT 0 CHECK (replies_count >= 0),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(), -- 评论发布时间
+    search_vector TSVECTOR AS (to_tsvector('english', content)) STORED
 );
 
-CREATE INDEX IF NOT EXISTS sorted_comments ON comments (created_at DESC);
+CREATE INDEX IF NOT EXISTS sorted_comments ON comments (created_at DESC, id DESC);
+
+CREATE INDEX IF NOT EXISTS sorted_comment_replies ON comments (parent_id, created_at DESC, id DESC);
+
+CREATE INVERTED INDEX IF NOT EXISTS searchable_comments ON comments (search_vector);
 
 -- 评论点赞的表
 CREATE TABLE IF NOT EXISTS comment_likes (
@@ -81,6 +217,20 @@ CREATE TABLE IF NOT EXISTS comment_likes (
     PRIMARY KEY (user_id, comment_id)
 );
 
+-- Hashtags used in posts and comments, lowercased and without the "#".
+-- Comment ones have a comment_id besides the post_id.
+CREATE TABLE IF NOT EXISTS tags (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    tag VARCHAR NOT NULL,
+    post_id UUID NOT NULL REFERENCES posts,
+    comment_id UUID REFERENCES comments,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS sorted_tags ON tags (tag, created_at DESC);
+
+CREATE INDEX IF NOT EXISTS recent_tags ON tags (created_at DESC);
+
 -- 通知表，当有新的关注，帖子有了评论，评论有了回复, 被别人@  这几种情况都应该进行通知
 CREATE TABLE IF NOT EXISTS notifications (
     id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
@@ -92,10 +242,52 @@ CREATE TABLE IF NOT EXISTS notifications (
     issued_at TIMESTAMPTZ NOT NULL DEFAULT now()
 );
 
-CREATE INDEX IF NOT EXISTS sorted_notifications ON notifications (issued_at DESC);
+CREATE INDEX IF NOT EXISTS sorted_notifications ON notifications (issued_at DESC, id DESC);
 
 CREATE UNIQUE INDEX IF NOT EXISTS unique_notifications ON notifications (user_id, type, post_id, read_at);
 
+-- Direct message threads. One-to-one ones have a direct_key out of both sorted user ids
+-- so there is only one per pair; group ones have none.
+CREATE TABLE IF NOT EXISTS conversations (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    direct_key VARCHAR UNIQUE,
+    last_message_id UUID, -- no foreign key since messages reference conversations
+    updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE TABLE IF NOT EXISTS conversation_members (
+    conversation_id UUID NOT NULL REFERENCES conversations,
+    user_id UUID NOT NULL REFERENCES users,
+    unread_count INT NOT NULL DEFAULT 0 CHECK (unread_count >= 0),
+    PRIMARY KEY (conversation_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS unread_conversation_members ON conversation_members (user_id, unread_count);
+
+CREATE TABLE IF NOT EXISTS messages (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    conversation_id UUID NOT NULL REFERENCES conversations,
+    user_id UUID NOT NULL REFERENCES users,
+    content VARCHAR NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX IF NOT EXISTS sorted_messages ON messages (conversation_id, created_at DESC, id DESC);
+
+-- Background account deletion jobs. No foreign key since they outlive the user.
+CREATE TABLE IF NOT EXISTS account_deletions (
+    id UUID NOT NULL PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL,
+    step VARCHAR NOT NULL DEFAULT 'pending',
+    progress INT NOT NULL DEFAULT 0 CHECK (progress >= 0 AND progress <= 100),
+    failure VARCHAR,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS sorted_account_deletions ON account_deletions (user_id, created_at DESC);
+
 -- 下面是插入一些用于测试的数据
 INSERT INTO users (id, email, username) VALUES
     ('24ca6ce6-b3e9-4276-a99a-45c77115cc9f', 'shinji@example.org', 'shinji'),
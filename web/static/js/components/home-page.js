@@ -5,7 +5,7 @@ import renderList from "./list.js"
 import renderPost from "./post.js"
 
 const PAGE_SIZE = 10
-let timeline = /** @type {import("../types.js").TimelineItem[]} */ (null)
+let timeline = /** @type {import("../types.js").Page<import("../types.js").TimelineItem>} */ (null)
 
 const template = document.createElement("template")
 template.innerHTML = `
@@ -23,12 +23,20 @@ template.innerHTML = `
 `
 
 export default async function renderHomePage() {
-    if (timeline === null || timeline.length === 0) {
+    if (timeline === null || timeline.items.length === 0) {
         timeline = await fetchTimeline()
     }
+    const loadMore = async before => {
+        const page = await fetchTimeline(before)
+        timeline.nextCursor = page.nextCursor
+        timeline.hasMore = page.hasMore
+        return page
+    }
     const list = renderList({
-        items: timeline,
-        loadMoreFunc: fetchTimeline,
+        items: timeline.items,
+        nextCursor: timeline.nextCursor,
+        hasMore: timeline.hasMore,
+        loadMoreFunc: loadMore,
         pageSize: PAGE_SIZE,
         renderItem: renderTimelineItem,
     })
@@ -122,7 +130,7 @@ async function publishPost(input) {
 
 /**
  * @param {string=} before
- * @returns {Promise<import("../types.js").TimelineItem[]>}
+ * @returns {Promise<import("../types.js").Page<import("../types.js").TimelineItem>>}
  */
 function fetchTimeline(before = "") {
     return doGet(`/api/timeline?before=${before}&last=${PAGE_SIZE}`)
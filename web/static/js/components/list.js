@@ -61,8 +61,10 @@ function renderFeed() {
  * @param {Object} opts
  * @param {any[]} opts.items
  * @param {function(any): HTMLElement} opts.renderItem
- * @param {function(any):Promise<any[]>} opts.loadMoreFunc
+ * @param {function(any):Promise<any[]|import("../types.js").Page<any>>} opts.loadMoreFunc
  * @param {number} opts.pageSize
+ * @param {boolean=} opts.hasMore with it set, loadMoreFunc receives opts.nextCursor and resolves to a page
+ * @param {string=} opts.nextCursor
  * @param {function(number):string=} opts.newItemsMessageFunc
  * @param {boolean=} opts.reverse
  * @param {function(any):any=} opts.getID
@@ -121,6 +123,8 @@ export default function renderList(opts) {
         }
     }
 
+    const paginatesByCursor = typeof opts.hasMore === "boolean"
+
     const onLoadMoreButtonClick = async () => {
         const lastItem = opts.items[opts.items.length - 1]
         const lastID = lastItem === undefined
@@ -133,7 +137,12 @@ export default function renderList(opts) {
         loadMoreButton.disabled = true
 
         try {
-            const newItems = await opts.loadMoreFunc(lastID)
+            const result = await opts.loadMoreFunc(paginatesByCursor ? opts.nextCursor : lastID)
+            const newItems = paginatesByCursor ? result.items : result
+            if (paginatesByCursor) {
+                opts.nextCursor = result.nextCursor
+                opts.hasMore = result.hasMore
+            }
 
             opts.items.push(...newItems)
 
@@ -145,7 +154,7 @@ export default function renderList(opts) {
                 }
             }
 
-            if (newItems.length < opts.pageSize) {
+            if (paginatesByCursor ? !opts.hasMore : newItems.length < opts.pageSize) {
                 loadMoreButton.removeEventListener("click", onLoadMoreButtonClick)
                 loadMoreButton.remove()
             }
@@ -170,7 +179,7 @@ export default function renderList(opts) {
         }
     }
 
-    if (opts.items.length === opts.pageSize) {
+    if (paginatesByCursor ? opts.hasMore : opts.items.length === opts.pageSize) {
         setTimeout(() => {
             loadMoreButton = document.createElement("button")
             loadMoreButton.className = "load-more-button"
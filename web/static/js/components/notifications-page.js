@@ -15,7 +15,9 @@ template.innerHTML = `
 export default async function renderNotificationsPage() {
     const notifications = await fetchNotifications()
     const list = renderList({
-        items: notifications,
+        items: notifications.items,
+        nextCursor: notifications.nextCursor,
+        hasMore: notifications.hasMore,
         loadMoreFunc: fetchNotifications,
         pageSize: PAGE_SIZE,
         renderItem: renderNotification,
@@ -40,7 +42,7 @@ export default async function renderNotificationsPage() {
 
 /**
  * @param {string=} before
- * @returns {Promise<import("../types.js").Notification[]>}
+ * @returns {Promise<import("../types.js").Page<import("../types.js").Notification>>}
  */
 function fetchNotifications(before = "") {
     return doGet(`/api/notifications?last=${PAGE_SIZE}&before=${encodeURIComponent(before)}`)
@@ -76,6 +78,9 @@ function renderNotification(notification) {
         case "follow":
             content += " followed you"
             break
+        case "follow_request":
+            content += " requested to follow you"
+            break
         case "comment":
             content += ` commented on a <a href="/posts/${encodeURIComponent(notification.postID)}">post</a>`
             break
@@ -38,7 +38,9 @@ export default async function renderPostPage(params) {
     ])
 
     const list = renderList({
-        items: comments,
+        items: comments.items,
+        nextCursor: comments.nextCursor,
+        hasMore: comments.hasMore,
         renderItem: renderComment,
         loadMoreFunc: before => fetchComments(post.id, before),
         pageSize: PAGE_SIZE,
@@ -235,7 +237,7 @@ function fetchPost(postID) {
 /**
  * @param {string} postID
  * @param {string=} before
- * @returns {Promise<import("../types.js").Comment[]>}
+ * @returns {Promise<import("../types.js").Page<import("../types.js").Comment>>}
  */
 function fetchComments(postID, before = "") {
     return doGet(`/api/posts/${postID}/comments?before=${before}&last=${PAGE_SIZE}`)
@@ -22,7 +22,9 @@ export default async function renderSearchPage() {
     const users = await fetchUsers(searchQuery)
     const list = renderList({
         getID: u => u.username,
-        items: users,
+        items: users.items,
+        nextCursor: users.nextCursor,
+        hasMore: users.hasMore,
         loadMoreFunc: after => fetchUsers(searchQuery, after),
         pageSize: PAGE_SIZE,
         renderItem: renderUserProfile,
@@ -55,7 +57,7 @@ export default async function renderSearchPage() {
 /**
  * @param {string} search
  * @param {string=} after
- * @returns {Promise<import("../types.js").UserProfile[]>}
+ * @returns {Promise<import("../types.js").Page<import("../types.js").UserProfile>>}
  */
 function fetchUsers(search, after = "") {
     return doGet(`/api/users?search=${search}&after=${after}&first=${PAGE_SIZE}`)
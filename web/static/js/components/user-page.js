@@ -27,20 +27,22 @@ export default async function renderUserPage(params) {
         fetchUser(params.username),
         fetchPosts(params.username),
     ])
-    for (const post of posts) {
+    for (const post of posts.items) {
         post.user = user
     }
 
     const loadMore = async before => {
         const posts = await fetchPosts(user.username, before)
-        for (const post of posts) {
+        for (const post of posts.items) {
             post.user = user
         }
         return posts
     }
 
     const list = renderList({
-        items: posts,
+        items: posts.items,
+        nextCursor: posts.nextCursor,
+        hasMore: posts.hasMore,
         loadMoreFunc: loadMore,
         pageSize: PAGE_SIZE,
         renderItem: renderPost,
@@ -69,7 +71,7 @@ function fetchUser(username) {
 /**
  * @param {string} username
  * @param {string=} before
- * @returns {Promise<import("../types.js").Post[]>}
+ * @returns {Promise<import("../types.js").Page<import("../types.js").Post>>}
  */
 function fetchPosts(username, before = "") {
     return doGet(`/api/users/${username}/posts?before=${before}&last=${PAGE_SIZE}`)
@@ -1,6 +1,6 @@
 import { isAuthenticated } from "../auth.js"
 import { doPost } from "../http.js"
-import { el, replaceNode } from "../utils.js"
+import { el, escapeHTML, linkify, replaceNode } from "../utils.js"
 import renderAvatarHTML from "./avatar.js"
 import { personAddIconSVG, personDoneIconSVG } from "./icons.js"
 
@@ -20,6 +20,14 @@ export default function renderUserProfile(user, full = false) {
             <a href="/users/${user.username}" class="user-username">${user.username}</a>
         `}
         ${user.followeed ? `<span class="badge">Follows you</span>` : ""}
+        ${user.displayName ? `<p class="user-display-name">${escapeHTML(user.displayName)}</p>` : ""}
+        ${full && user.bio ? `<p class="user-bio">${linkify(escapeHTML(user.bio))}</p>` : ""}
+        ${full && (user.location || user.website) ? `
+            <p class="user-details">
+                ${user.location ? `<span class="label">${escapeHTML(user.location)}</span>` : ""}
+                ${user.website ? `<a href="${escapeHTML(user.website)}" target="_blank" rel="noopener">${escapeHTML(user.website)}</a>` : ""}
+            </p>
+        ` : ""}
         ${authenticated && !user.me ? `
             <div class="user-controls">
                 <button class="follow-button" aria-pressed="${user.following}">
@@ -33,7 +41,7 @@ export default function renderUserProfile(user, full = false) {
                     <svg class="icon" xmlns="http://www.w3.org/2000/svg" viewBox="0 0 24 24"><g data-name="Layer 2"><g data-name="log-out"><rect width="24" height="24" transform="rotate(90 12 12)" opacity="0"/><path d="M7 6a1 1 0 0 0 0-2H5a1 1 0 0 0-1 1v14a1 1 0 0 0 1 1h2a1 1 0 0 0 0-2H6V6z"/><path d="M20.82 11.42l-2.82-4a1 1 0 0 0-1.39-.24 1 1 0 0 0-.24 1.4L18.09 11H10a1 1 0 0 0 0 2h8l-1.8 2.4a1 1 0 0 0 .2 1.4 1 1 0 0 0 .6.2 1 1 0 0 0 .8-.4l3-4a1 1 0 0 0 .02-1.18z"/></g></g></svg>
                     <span>Logout</span>
                 </button>
-                <input class="js-avatar-input" type="file" name="avatar" accept="image/png,image/jpeg" required hidden>
+                <input class="js-avatar-input" type="file" name="avatar" accept="image/png,image/jpeg,image/gif,image/webp" required hidden>
             </div>
         ` : ""}
         <div class="user-stats">
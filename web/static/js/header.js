@@ -92,6 +92,7 @@ function getNotificationBody(notification) {
     const actorsText = joinActors(notification.actors)
     switch (notification.type) {
         case "follow": return actorsText + " followed you"
+        case "follow_request": return actorsText + " requested to follow you"
         case "comment": return actorsText + " commented on a post"
         case "post_mention": return actorsText + " mentioned you on a post"
         case "comment_mention": return actorsText + " mentioned you on a comment"
@@ -116,7 +117,8 @@ export function joinActors(actors) {
  */
 function getNotificationHref(notification) {
     switch (notification.type) {
-        case "follow": return `/users/${encodeURIComponent(notification.actors[0])}`
+        case "follow":
+        case "follow_request": return `/users/${encodeURIComponent(notification.actors[0])}`
         case "comment":
         case "post_mention":
         case "comment_mention": return `/posts/${encodeURIComponent(notification.postID)}`
@@ -2,7 +2,7 @@
  * @typedef User
  * @property {string=} id
  * @property {string} username
- * @property {string=} avatarURL
+ * @property {string} avatarURL
  */
 
 /**
@@ -10,12 +10,19 @@
  * @property {string=} id
  * @property {string=} email
  * @property {string} username
- * @property {string=} avatarURL
+ * @property {string} avatarURL
  * @property {number} followersCount
  * @property {number} followeesCount
+ * @property {string=} displayName
+ * @property {string=} bio
+ * @property {string=} location
+ * @property {string=} website
+ * @property {boolean} private
  * @property {boolean} me
  * @property {boolean} following
  * @property {boolean} followeed
+ * @property {boolean} requested
+ * @property {boolean} blocked
  */
 
 /**
@@ -30,6 +37,7 @@
  * @property {string} id
  * @property {string} content
  * @property {boolean} NSFW
+ * @property {"public"|"followers"|"mentioned"} visibility
  * @property {string=} spoilerOf
  * @property {number} likesCount
  * @property {number} commentsCount
@@ -68,6 +76,58 @@
  * @typedef ToggleFollowOutput
  * @property {number} followersCount
  * @property {boolean} following
+ * @property {boolean} requested
+ */
+
+/**
+ * @typedef ToggleBlockOutput
+ * @property {boolean} blocked
+ */
+
+/**
+ * @typedef Mute
+ * @property {string} id
+ * @property {User=} user
+ * @property {string=} word
+ * @property {string|Date|null} expiresAt
+ * @property {string|Date} createdAt
+ */
+
+/**
+ * @template T
+ * @typedef Page
+ * @property {T[]} items
+ * @property {string|null} nextCursor
+ * @property {boolean} hasMore
+ */
+
+/**
+ * @typedef SearchHit
+ * @property {"post"|"comment"} type
+ * @property {string} postID
+ * @property {Post=} post
+ * @property {Comment=} comment
+ */
+
+/**
+ * @typedef SearchPostsOutput
+ * @property {SearchHit[]} hits
+ * @property {string|null} nextCursor
+ * @property {boolean} hasMore
+ */
+
+/**
+ * @typedef RankedTimelineOutput
+ * @property {TimelineItem[]} items
+ * @property {string|null} nextCursor
+ * @property {boolean} hasMore
+ */
+
+/**
+ * @typedef TrendingTag
+ * @property {string} tag
+ * @property {number} usesCount
+ * @property {number} postsCount
  */
 
 /**
@@ -80,10 +140,30 @@
  * @typedef Notification
  * @property {string} id
  * @property {string[]} actors
- * @property {"follow"|"comment"|"post_mention"|"comment_mention"} type
+ * @property {"follow"|"follow_request"|"comment"|"post_mention"|"comment_mention"} type
  * @property {string=} postID
  * @property {boolean} read
  * @property {string|Date} issuedAt
  */
 
+/**
+ * @typedef Conversation
+ * @property {string} id
+ * @property {User[]} members
+ * @property {boolean} group
+ * @property {Message=} lastMessage
+ * @property {number} unreadCount
+ * @property {string|Date} updatedAt
+ */
+
+/**
+ * @typedef Message
+ * @property {string} id
+ * @property {string} conversationID
+ * @property {string} content
+ * @property {User=} user
+ * @property {boolean} mine
+ * @property {string|Date} createdAt
+ */
+
 export default undefined